@@ -0,0 +1,114 @@
+package main
+
+import (
+	"cse224/proj4/pkg/surfstore"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Arguments
+const ARG_COUNT int = 3
+
+// Usage strings
+const USAGE_STRING = "./run-admin.sh -d -tls -ca file -cert file -key file host:port add|remove blockStoreAddr[=weight]"
+
+const DEBUG_NAME = "d"
+const DEBUG_USAGE = "Output log statements"
+
+const ADDR_NAME = "host:port"
+const ADDR_USAGE = "IP address and port of the MetaStore (comma separated for a Raft cluster)"
+
+const COMMAND_NAME = "add|remove"
+const COMMAND_USAGE = "Add a BlockStore to the hash ring (or change its weight), or remove one"
+
+const BLOCKSTORE_NAME = "blockStoreAddr[=weight]"
+const BLOCKSTORE_USAGE = "Address of the BlockStore, optionally with a weight when adding it"
+
+const TOKEN_USAGE = "Token identifying an administrator to servers that require authentication"
+
+// Exit codes
+const EX_USAGE int = 64
+
+func main() {
+	// Flags shared by the client commands
+	tlsFlags := surfstore.RegisterClientTLSFlags(flag.CommandLine)
+
+	// Custom flag Usage message
+	flag.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s:\n", USAGE_STRING)
+		fmt.Fprintf(w, "  -%s: %v\n", DEBUG_NAME, DEBUG_USAGE)
+		tlsFlags.PrintUsage(w)
+		fmt.Fprintf(w, "  %s: %v\n", ADDR_NAME, ADDR_USAGE)
+		fmt.Fprintf(w, "  %s: %v\n", COMMAND_NAME, COMMAND_USAGE)
+		fmt.Fprintf(w, "  %s: %v\n", BLOCKSTORE_NAME, BLOCKSTORE_USAGE)
+		fmt.Fprintf(w, "  $%s: %v\n", surfstore.SERVER_TOKEN_ENV, TOKEN_USAGE)
+	}
+
+	// Parse command-line arguments and flags
+	debug := flag.Bool("d", false, DEBUG_USAGE)
+	flag.Parse()
+
+	// Use tail arguments to hold non-flag arguments
+	args := flag.Args()
+
+	if len(args) != ARG_COUNT {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+
+	hostPort := args[0]
+	command := strings.ToLower(args[1])
+	blockStoreAddr := args[2]
+	weight := 1
+	if i := strings.LastIndex(blockStoreAddr, "="); i >= 0 {
+		var err error
+		weight, err = strconv.Atoi(blockStoreAddr[i+1:])
+		if err != nil || weight < 1 || command != "add" {
+			flag.Usage()
+			os.Exit(EX_USAGE)
+		}
+		blockStoreAddr = blockStoreAddr[:i]
+	}
+
+	dialOpt, err := tlsFlags.DialOption()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not set up TLS: ", err)
+		os.Exit(1)
+	}
+
+	// Disable log outputs if debug flag is missing
+	if !(*debug) {
+		log.SetFlags(0)
+		log.SetOutput(ioutil.Discard)
+	}
+
+	rpcClient := surfstore.NewSurfstoreRPCClient(hostPort, "", 0)
+	rpcClient.DialOption = dialOpt
+	rpcClient.Token = os.Getenv(surfstore.SERVER_TOKEN_ENV)
+	defer rpcClient.Close()
+
+	var succ bool
+	switch command {
+	case "add":
+		err = rpcClient.AddBlockStore(blockStoreAddr, weight, &succ)
+	case "remove":
+		err = rpcClient.RemoveBlockStore(blockStoreAddr, &succ)
+	default:
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+	if err != nil {
+		log.Fatal("[Surfstore RPCClient]:", "Error During Changing BlockStores ", err)
+	}
+	if !succ {
+		fmt.Println("Rejected: " + blockStoreAddr)
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
@@ -1,49 +1,116 @@
 package main
 
 import (
+	"context"
 	"cse224/proj4/pkg/surfstore"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 )
 
 // Arguments
 const ARG_COUNT int = 3
 
 // Usage strings
-const USAGE_STRING = "./run-client.sh -d host:port baseDir blockSize"
+const USAGE_STRING = "./run-client.sh -d -j concurrency -name clientName -chunking mode -min size -max size -compress algorithm -encrypt -convergent -tls -ca file -cert file -key file -timeout list -retries n -daemon -debounce duration -poll duration host:port baseDir blockSize"
 
 const DEBUG_NAME = "d"
 const DEBUG_USAGE = "Output log statements"
 
+const CONCURRENCY_NAME = "j"
+const CONCURRENCY_USAGE = "Number of files and block transfers handled concurrently"
+
+const CLIENT_NAME = "name"
+const CLIENT_USAGE = "Name of this client used in conflict copies"
+
+const CHUNKING_NAME = "chunking"
+const CHUNKING_USAGE = "How files are split into blocks: fixed (every blockSize bytes) or cdc (content-defined, averaging blockSize bytes)"
+
+const MIN_NAME = "min"
+const MIN_USAGE = "Smallest block cdc chunking cuts"
+
+const MAX_NAME = "max"
+const MAX_USAGE = "Largest block cdc chunking cuts"
+
+const COMPRESS_NAME = "compress"
+const COMPRESS_USAGE = "Compression of uploaded blocks: none, gzip or snappy"
+
+const TIMEOUT_NAME = "timeout"
+const TIMEOUT_USAGE = "Call deadlines: a duration for all unary calls and/or name=duration per call, comma separated, e.g. 2s,GetBlock=10s,PutBlocks=1m"
+
+const RETRIES_NAME = "retries"
+const RETRIES_USAGE = "Attempts at idempotent calls (GetBlock, PutBlock, HasBlocks, GetFileInfoMap, GetChangesSince) failing with transient errors, retried with exponential backoff"
+
+const DAEMON_NAME = "daemon"
+const DAEMON_USAGE = "Keep running and sync changes as they happen, until interrupted"
+
+const DEBOUNCE_NAME = "debounce"
+const DEBOUNCE_USAGE = "With -daemon, how long local changes must settle before they are synced"
+
+const POLL_NAME = "poll"
+const POLL_USAGE = "With -daemon, how often the MetaStore is checked for remote changes"
+
 const ADDR_NAME = "host:port"
-const ADDR_USAGE = "IP address and port of the MetaStore the client is syncing to"
+const ADDR_USAGE = "IP address and port of the MetaStore the client is syncing to (comma separated for a Raft cluster)"
 
 const BASEDIR_NAME = "baseDir"
 const BASEDIR_USAGE = "Base directory of the client"
 
 const BLOCK_NAME = "blockSize"
-const BLOCK_USAGE = "Size of the blocks used to fragment files"
+const BLOCK_USAGE = "Size of the blocks used to fragment files (average size with cdc chunking)"
+
+const TOKEN_USAGE = "Token identifying the user to servers that require authentication"
 
 // Exit codes
 const EX_USAGE int = 64
 
 func main() {
+	// Flags shared by the client commands
+	cipherFlags := surfstore.RegisterClientCipherFlags(flag.CommandLine)
+	tlsFlags := surfstore.RegisterClientTLSFlags(flag.CommandLine)
+
 	// Custom flag Usage message
 	flag.Usage = func() {
 		w := flag.CommandLine.Output()
 		fmt.Fprintf(w, "Usage of %s:\n", USAGE_STRING)
 		fmt.Fprintf(w, "  -%s: %v\n", DEBUG_NAME, DEBUG_USAGE)
+		fmt.Fprintf(w, "  -%s: %v (default = %v)\n", CONCURRENCY_NAME, CONCURRENCY_USAGE, surfstore.DEFAULT_CONCURRENCY)
+		fmt.Fprintf(w, "  -%s: %v (default = hostname)\n", CLIENT_NAME, CLIENT_USAGE)
+		fmt.Fprintf(w, "  -%s: %v (default = fixed)\n", CHUNKING_NAME, CHUNKING_USAGE)
+		fmt.Fprintf(w, "  -%s: %v (default = blockSize/4)\n", MIN_NAME, MIN_USAGE)
+		fmt.Fprintf(w, "  -%s: %v (default = blockSize*4)\n", MAX_NAME, MAX_USAGE)
+		fmt.Fprintf(w, "  -%s: %v (default = none)\n", COMPRESS_NAME, COMPRESS_USAGE)
+		cipherFlags.PrintUsage(w)
+		tlsFlags.PrintUsage(w)
+		fmt.Fprintf(w, "  -%s: %v (default = %v)\n", TIMEOUT_NAME, TIMEOUT_USAGE, surfstore.DEFAULT_RPC_TIMEOUT)
+		fmt.Fprintf(w, "  -%s: %v (default = %v)\n", RETRIES_NAME, RETRIES_USAGE, surfstore.DEFAULT_RETRY_ATTEMPTS)
+		fmt.Fprintf(w, "  -%s: %v\n", DAEMON_NAME, DAEMON_USAGE)
+		fmt.Fprintf(w, "  -%s: %v (default = %v)\n", DEBOUNCE_NAME, DEBOUNCE_USAGE, surfstore.DEFAULT_DEBOUNCE)
+		fmt.Fprintf(w, "  -%s: %v (default = %v)\n", POLL_NAME, POLL_USAGE, surfstore.DEFAULT_POLL_INTERVAL)
 		fmt.Fprintf(w, "  %s: %v\n", ADDR_NAME, ADDR_USAGE)
 		fmt.Fprintf(w, "  %s: %v\n", BASEDIR_NAME, BASEDIR_USAGE)
 		fmt.Fprintf(w, "  %s: %v\n", BLOCK_NAME, BLOCK_USAGE)
+		fmt.Fprintf(w, "  $%s: %v\n", surfstore.TOKEN_ENV, TOKEN_USAGE)
 	}
 
 	// Parse command-line arguments and flags
 	debug := flag.Bool("d", false, DEBUG_USAGE)
+	concurrency := flag.Int("j", surfstore.DEFAULT_CONCURRENCY, CONCURRENCY_USAGE)
+	clientName := flag.String("name", "", CLIENT_USAGE)
+	chunking := flag.String("chunking", surfstore.FIXED_CHUNKING, CHUNKING_USAGE)
+	minSize := flag.Int("min", 0, MIN_USAGE)
+	maxSize := flag.Int("max", 0, MAX_USAGE)
+	compress := flag.String("compress", "none", COMPRESS_USAGE)
+	timeout := flag.String("timeout", "", TIMEOUT_USAGE)
+	retries := flag.Int("retries", surfstore.DEFAULT_RETRY_ATTEMPTS, RETRIES_USAGE)
+	daemon := flag.Bool("daemon", false, DAEMON_USAGE)
+	debounce := flag.Duration("debounce", surfstore.DEFAULT_DEBOUNCE, DEBOUNCE_USAGE)
+	poll := flag.Duration("poll", surfstore.DEFAULT_POLL_INTERVAL, POLL_USAGE)
 	flag.Parse()
 
 	// Use tail arguments to hold non-flag arguments
@@ -57,11 +124,50 @@ func main() {
 	hostPort := args[0]
 	baseDir := args[1]
 	blockSize, err := strconv.Atoi(args[2])
+	if err != nil || *concurrency < 1 || *retries < 1 || *debounce < 0 || *poll <= 0 {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+
+	if *minSize == 0 {
+		*minSize = blockSize / 4
+	}
+	if *maxSize == 0 {
+		*maxSize = blockSize * 4
+	}
+	chunker, err := surfstore.NewChunker(*chunking, *minSize, blockSize, *maxSize)
 	if err != nil {
+		fmt.Fprintln(flag.CommandLine.Output(), err)
 		flag.Usage()
 		os.Exit(EX_USAGE)
 	}
 
+	compression, err := surfstore.ParseCompression(*compress)
+	if err != nil {
+		fmt.Fprintln(flag.CommandLine.Output(), err)
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+
+	timeouts, err := surfstore.ParseTimeouts(*timeout)
+	if err != nil {
+		fmt.Fprintln(flag.CommandLine.Output(), err)
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+
+	cipher, err := cipherFlags.Cipher()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not derive encryption key: ", err)
+		os.Exit(EX_USAGE)
+	}
+
+	dialOpt, err := tlsFlags.DialOption()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not set up TLS: ", err)
+		os.Exit(1)
+	}
+
 	// Disable log outputs if debug flag is missing
 	if !(*debug) {
 		log.SetFlags(0)
@@ -69,5 +175,27 @@ func main() {
 	}
 
 	rpcClient := surfstore.NewSurfstoreRPCClient(hostPort, baseDir, blockSize)
+	rpcClient.Concurrency = *concurrency
+	rpcClient.Chunker = chunker
+	rpcClient.Compression = compression
+	rpcClient.Cipher = cipher
+	rpcClient.DialOption = dialOpt
+	rpcClient.Token = os.Getenv(surfstore.TOKEN_ENV)
+	rpcClient.Timeouts = timeouts
+	rpcClient.Retry.MaxAttempts = *retries
+	if *clientName != "" {
+		rpcClient.ClientName = *clientName
+	}
+	defer rpcClient.Close()
+
+	if *daemon {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := surfstore.ClientDaemon(ctx, rpcClient, *debounce, *poll); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not watch base directory: ", err)
+			os.Exit(1)
+		}
+		return
+	}
 	surfstore.ClientSync(rpcClient)
 }
@@ -14,13 +14,13 @@ import (
 const ARG_COUNT int = 3
 
 // Usage strings
-const USAGE_STRING = "./run-client.sh -d host:port baseDir blockSize"
+const USAGE_STRING = "./run-client.sh -d -tls -ca file -cert file -key file host:port baseDir blockSize"
 
 const DEBUG_NAME = "d"
 const DEBUG_USAGE = "Output log statements"
 
 const ADDR_NAME = "host:port"
-const ADDR_USAGE = "IP address and port of the MetaStore the client is syncing to"
+const ADDR_USAGE = "IP address and port of the MetaStore the client is syncing to (comma separated for a Raft cluster)"
 
 const BASEDIR_NAME = "baseDir"
 const BASEDIR_USAGE = "Base directory of the client"
@@ -28,18 +28,25 @@ const BASEDIR_USAGE = "Base directory of the client"
 const BLOCK_NAME = "blockSize"
 const BLOCK_USAGE = "Size of the blocks used to fragment files"
 
+const TOKEN_USAGE = "Token identifying an administrator to servers that require authentication"
+
 // Exit codes
 const EX_USAGE int = 64
 
 func main() {
+	// Flags shared by the client commands
+	tlsFlags := surfstore.RegisterClientTLSFlags(flag.CommandLine)
+
 	// Custom flag Usage message
 	flag.Usage = func() {
 		w := flag.CommandLine.Output()
 		fmt.Fprintf(w, "Usage of %s:\n", USAGE_STRING)
 		fmt.Fprintf(w, "  -%s: %v\n", DEBUG_NAME, DEBUG_USAGE)
+		tlsFlags.PrintUsage(w)
 		fmt.Fprintf(w, "  %s: %v\n", ADDR_NAME, ADDR_USAGE)
 		fmt.Fprintf(w, "  %s: %v\n", BASEDIR_NAME, BASEDIR_USAGE)
 		fmt.Fprintf(w, "  %s: %v\n", BLOCK_NAME, BLOCK_USAGE)
+		fmt.Fprintf(w, "  $%s: %v\n", surfstore.SERVER_TOKEN_ENV, TOKEN_USAGE)
 	}
 
 	// Parse command-line arguments and flags
@@ -62,6 +69,12 @@ func main() {
 		os.Exit(EX_USAGE)
 	}
 
+	dialOpt, err := tlsFlags.DialOption()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not set up TLS: ", err)
+		os.Exit(1)
+	}
+
 	// Disable log outputs if debug flag is missing
 	if !(*debug) {
 		log.SetFlags(0)
@@ -69,6 +82,9 @@ func main() {
 	}
 
 	rpcClient := surfstore.NewSurfstoreRPCClient(hostPort, baseDir, blockSize)
+	rpcClient.DialOption = dialOpt
+	rpcClient.Token = os.Getenv(surfstore.SERVER_TOKEN_ENV)
+	defer rpcClient.Close()
 	PrintBlocksOnEachServer(rpcClient)
 }
 
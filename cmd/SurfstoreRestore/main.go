@@ -0,0 +1,241 @@
+package main
+
+import (
+	"cse224/proj4/pkg/surfstore"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Usage strings
+const USAGE_STRING = "./run-restore.sh -d -tls -ca file -cert file -key file -encrypt -convergent host:port list [path] | restore path version|time | trash [path] | undelete path"
+
+const DEBUG_NAME = "d"
+const DEBUG_USAGE = "Output log statements"
+
+const ADDR_NAME = "host:port"
+const ADDR_USAGE = "IP address and port of the MetaStore (comma separated for a Raft cluster)"
+
+const COMMAND_NAME = "list [path] | restore path version|time | trash [path] | undelete path"
+const COMMAND_USAGE = "List the versions kept of a file or of the files in a directory (all files if no path), or restore a file to a version, or a file or directory (\"/\" for all files) to a point in time: a date like 2006-01-02T15:04:05 in local time or RFC 3339, or a duration ago like 2h. List the deleted files in the trash, or undelete a file or a directory"
+
+const TOKEN_USAGE = "Token identifying the user to servers that require authentication"
+
+// Layouts of points in time given in local time
+var TIME_LAYOUTS = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// Exit codes
+const EX_USAGE int = 64
+
+func main() {
+	// Flags shared by the client commands
+	tlsFlags := surfstore.RegisterClientTLSFlags(flag.CommandLine)
+	cipherFlags := surfstore.RegisterClientCipherFlags(flag.CommandLine)
+
+	// Custom flag Usage message
+	flag.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s:\n", USAGE_STRING)
+		fmt.Fprintf(w, "  -%s: %v\n", DEBUG_NAME, DEBUG_USAGE)
+		tlsFlags.PrintUsage(w)
+		cipherFlags.PrintUsage(w)
+		fmt.Fprintf(w, "  %s: %v\n", ADDR_NAME, ADDR_USAGE)
+		fmt.Fprintf(w, "  %s: %v\n", COMMAND_NAME, COMMAND_USAGE)
+		fmt.Fprintf(w, "  $%s: %v\n", surfstore.TOKEN_ENV, TOKEN_USAGE)
+	}
+
+	// Parse command-line arguments and flags
+	debug := flag.Bool("d", false, DEBUG_USAGE)
+	flag.Parse()
+
+	// Use tail arguments to hold non-flag arguments
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+	hostPort := args[0]
+	command := strings.ToLower(args[1])
+	path := ""
+	switch {
+	case (command == "list" || command == "trash") && len(args) <= 3:
+		if len(args) == 3 {
+			path = strings.Trim(args[2], "/")
+		}
+	case command == "restore" && len(args) == 4:
+		path = strings.Trim(args[2], "/")
+	case command == "undelete" && len(args) == 3:
+		path = strings.Trim(args[2], "/")
+		if path == "" {
+			flag.Usage()
+			os.Exit(EX_USAGE)
+		}
+	default:
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+
+	// a version number, or else a point in time
+	var version int64 = -1
+	var at time.Time
+	if command == "restore" {
+		var err error
+		if version, err = strconv.ParseInt(args[3], 10, 32); err != nil {
+			version = -1
+			if at, err = ParsePointInTime(args[3]); err != nil {
+				fmt.Fprintln(flag.CommandLine.Output(), err)
+				flag.Usage()
+				os.Exit(EX_USAGE)
+			}
+		} else if path == "" {
+			// only single files have version numbers
+			flag.Usage()
+			os.Exit(EX_USAGE)
+		}
+	}
+
+	dialOpt, err := tlsFlags.DialOption()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not set up TLS: ", err)
+		os.Exit(1)
+	}
+
+	cipher, err := cipherFlags.Cipher()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not derive encryption key: ", err)
+		os.Exit(EX_USAGE)
+	}
+
+	// Disable log outputs if debug flag is missing
+	if !(*debug) {
+		log.SetFlags(0)
+		log.SetOutput(ioutil.Discard)
+	}
+
+	rpcClient := surfstore.NewSurfstoreRPCClient(hostPort, "", 0)
+	rpcClient.DialOption = dialOpt
+	rpcClient.Token = os.Getenv(surfstore.TOKEN_ENV)
+	rpcClient.Cipher = cipher
+	defer rpcClient.Close()
+
+	if command == "list" {
+		var versions []*surfstore.FileMetaData
+		if err := rpcClient.GetFileVersions(path, &versions); err != nil {
+			log.Fatal("[Surfstore RPCClient]:", "Error During Fetching Versions ", err)
+		}
+		PrintVersions(versions)
+		return
+	}
+
+	if command == "trash" || command == "undelete" {
+		var files []*surfstore.TrashedFile
+		var err error
+		if command == "trash" {
+			err = rpcClient.GetTrash(path, &files)
+		} else {
+			err = rpcClient.Undelete(path, &files)
+		}
+		if err != nil {
+			log.Fatal("[Surfstore RPCClient]:", "Error During Accessing Trash ", err)
+		}
+		if command == "trash" {
+			PrintTrash(files)
+			return
+		}
+		if len(files) == 0 {
+			fmt.Println("Nothing to undelete: " + path)
+			os.Exit(1)
+		}
+		for _, trashed := range files {
+			fmt.Println("Undeleted: " + trashed.FileMetaData.Filename)
+		}
+		return
+	}
+
+	if version >= 0 {
+		restored, err := surfstore.RestoreVersion(rpcClient, path, int32(version))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not restore "+path+": ", err)
+			os.Exit(1)
+		}
+		if restored {
+			fmt.Println("Restored: " + path)
+		} else {
+			fmt.Println("Unchanged: " + path)
+		}
+		return
+	}
+
+	restored, unknown, err := surfstore.RestoreAsOf(rpcClient, path, at)
+	for _, filename := range restored {
+		fmt.Println("Restored: " + filename)
+	}
+	for _, filename := range unknown {
+		fmt.Println("No longer kept: " + filename)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not restore "+path+": ", err)
+		os.Exit(1)
+	}
+}
+
+// Parse a point in time: RFC 3339, a date and time in local time, or a
+// duration before now
+func ParsePointInTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	for _, layout := range TIME_LAYOUTS {
+		if at, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return at, nil
+		}
+	}
+	if ago, err := time.ParseDuration(value); err == nil && ago >= 0 {
+		return time.Now().Add(-ago), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid version or time: %q", value)
+}
+
+// One line per version: filename, version, when it was committed and what
+// it holds
+func PrintVersions(versions []*surfstore.FileMetaData) {
+	for _, fileMetaData := range versions {
+		fmt.Printf("%v\t%v\t%v\t%v\n", fileMetaData.Filename, fileMetaData.Version, FormatTime(fileMetaData.CommittedAt), Content(fileMetaData))
+	}
+}
+
+// One line per deleted file: filename, its last version, when it was
+// deleted and what it held
+func PrintTrash(files []*surfstore.TrashedFile) {
+	for _, trashed := range files {
+		fileMetaData := trashed.FileMetaData
+		fmt.Printf("%v\t%v\t%v\t%v\n", fileMetaData.Filename, fileMetaData.Version, FormatTime(trashed.DeletedAt), Content(fileMetaData))
+	}
+}
+
+// A time in milliseconds since the epoch, "-" if unknown
+func FormatTime(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Format(time.RFC3339)
+}
+
+func Content(fileMetaData *surfstore.FileMetaData) string {
+	if len(fileMetaData.BlockHashList) == 1 {
+		switch fileMetaData.BlockHashList[0] {
+		case surfstore.TOMBSTONE_HASHVALUE:
+			return "deleted"
+		case surfstore.EMPTYFILE_HASHVALUE:
+			return "empty"
+		case surfstore.DIRECTORY_HASHVALUE:
+			return "directory"
+		}
+	}
+	return fmt.Sprintf("%v blocks", len(fileMetaData.BlockHashList))
+}
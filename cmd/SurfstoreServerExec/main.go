@@ -15,7 +15,7 @@ import (
 )
 
 // Usage String
-const USAGE_STRING = "./run-server.sh -s <service_type> -p <port> -l -d (blockStoreAddr*)"
+const USAGE_STRING = "./run-server.sh -s <service_type> -p <port> -l -d -b <backend> -blockdir <dir> -datadir <dir> -peers <addrs> -id <id> -r <replicas> -vnodes <n> -gc <interval> -gcgrace <duration> -history <n> -trash <duration> -cert <file> -key <file> -ca <file> -mtls -users <file> -certusers -servercerts <names> (blockStoreAddr[=weight]*)"
 
 // Set of valid services
 var SERVICE_TYPES = map[string]bool{"meta": true, "block": true, "both": true}
@@ -31,7 +31,7 @@ func main() {
 		flag.VisitAll(func(f *flag.Flag) {
 			fmt.Fprintf(w, "  -%s: %v\n", f.Name, f.Usage)
 		})
-		fmt.Fprintf(w, "  (blockStoreAddr*): BlockStore Address (include self if service type is both)\n")
+		fmt.Fprintf(w, "  (blockStoreAddr[=weight]*): BlockStore Address (include self if service type is both), optionally with a weight for the hash ring\n")
 	}
 
 	// Parse command-line argument flags
@@ -39,12 +39,41 @@ func main() {
 	port := flag.Int("p", 8080, "(default = 8080) Port to accept connections")
 	localOnly := flag.Bool("l", false, "Only listen on localhost")
 	debug := flag.Bool("d", false, "Output log statements")
+	backend := flag.String("b", surfstore.MEMORY_BACKEND, "(default = memory) Block storage backend: memory, disk")
+	blockDir := flag.String("blockdir", "blocks", "(default = blocks) Directory holding blocks for the disk backend")
+	dataDir := flag.String("datadir", "", "Directory holding the MetaStore write-ahead log and snapshots, or the Raft log with -peers (in-memory if empty)")
+	peers := flag.String("peers", "", "Comma separated addresses of every MetaStore in a Raft cluster, including this one")
+	id := flag.Int("id", 0, "(default = 0) Index of this server in -peers")
+	replicas := flag.Int("r", 1, "(default = 1) Number of block servers each block is replicated on")
+	vnodes := flag.Int("vnodes", 1, "(default = 1) Virtual nodes per unit of weight each block server gets on the hash ring")
+	gcInterval := flag.Duration("gc", 0, "(default = 0, disabled) Interval between garbage collections of unreferenced blocks, e.g. 1h")
+	gcGrace := flag.Duration("gcgrace", surfstore.DEFAULT_GC_GRACE_PERIOD, "(default = 10m) Blocks written or checked by a client this recently are never collected or removed by a rebalance")
+	history := flag.Int("history", surfstore.DEFAULT_HISTORY_LIMIT, fmt.Sprintf("(default = %v) Earlier versions of each file the MetaStore keeps, which can be restored", surfstore.DEFAULT_HISTORY_LIMIT))
+	trash := flag.Duration("trash", surfstore.DEFAULT_TRASH_RETENTION, fmt.Sprintf("(default = %v) How long deleted files stay in the trash, from where they can be undeleted (0 disables the trash)", surfstore.DEFAULT_TRASH_RETENTION))
+	certFile := flag.String("cert", "", "TLS certificate of this server (plaintext if empty), also presented when calling other servers")
+	keyFile := flag.String("key", "", "Private key of the TLS certificate")
+	caFile := flag.String("ca", "", "CA certificate other servers and, with -mtls, clients are verified against (system roots if empty)")
+	mutualTLS := flag.Bool("mtls", false, "Only accept clients presenting a certificate signed by -ca")
+	usersFile := flag.String("users", "", "File of \"user token\" lines; clients must present one of the tokens and each user gets its own namespace")
+	certUsers := flag.Bool("certusers", false, "With -mtls, identify users by the common name of their client certificate")
+	serverCerts := flag.String("servercerts", "", "With -mtls, comma separated common names of the client certificates of servers and administrators")
 	flag.Parse()
 
 	// Use tail arguments to hold BlockStore address
 	args := flag.Args()
 	blockStoreAddrs := []string{}
-	for _, addr := range args {
+	weights := map[string]int{}
+	for _, arg := range args {
+		addr := arg
+		if i := strings.LastIndex(arg, "="); i >= 0 {
+			weight, err := strconv.Atoi(arg[i+1:])
+			if err != nil || weight < 1 {
+				flag.Usage()
+				os.Exit(EX_USAGE)
+			}
+			addr = arg[:i]
+			weights[addr] = weight
+		}
 		blockStoreAddrs = append(blockStoreAddrs, addr)
 	}
 
@@ -72,25 +101,124 @@ func main() {
 		log.SetOutput(ioutil.Discard)
 	}
 
-	log.Fatal(startServer(addr, strings.ToLower(*service), blockStoreAddrs))
+	serviceType := strings.ToLower(*service)
+	raftPeers := []string{}
+	if *peers != "" {
+		raftPeers = strings.Split(*peers, surfstore.CONFIG_DELIMITER)
+		if *id < 0 || *id >= len(raftPeers) || serviceType == "block" {
+			flag.Usage()
+			os.Exit(EX_USAGE)
+		}
+	}
+	if *replicas < 1 || *vnodes < 1 || *gcInterval < 0 || *gcGrace < 0 || *history < 0 || *trash < 0 {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+
+	// servers talking to each other use the same certificates
+	serverOpts := []grpc.ServerOption{surfstore.KeepaliveServerOption()}
+	var dialOpt grpc.DialOption
+	if *certFile != "" || *keyFile != "" || *mutualTLS {
+		tlsConfig := &surfstore.TLSConfig{CertFile: *certFile, KeyFile: *keyFile, CAFile: *caFile, MutualTLS: *mutualTLS}
+		serverOpt, err := tlsConfig.ServerOption()
+		if err == nil {
+			dialOpt, err = tlsConfig.DialOption()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not set up TLS: ", err)
+			os.Exit(1)
+		}
+		serverOpts = append(serverOpts, serverOpt)
+	}
+
+	// with authentication every call must come from a known user, except
+	// calls between servers and from administrators, which present the
+	// token in $SURFSTORE_SERVER_TOKEN or a certificate named in -servercerts
+	serverToken := os.Getenv(surfstore.SERVER_TOKEN_ENV)
+	serverNames := []string{}
+	if *serverCerts != "" {
+		serverNames = strings.Split(*serverCerts, surfstore.CONFIG_DELIMITER)
+	}
+	if *usersFile != "" || *certUsers {
+		if (*certUsers || len(serverNames) > 0) && !*mutualTLS {
+			flag.Usage()
+			os.Exit(EX_USAGE)
+		}
+		if serverToken == "" && len(serverNames) == 0 {
+			fmt.Fprintf(os.Stderr, "Authentication needs $%s or -servercerts to identify servers\n", surfstore.SERVER_TOKEN_ENV)
+			os.Exit(EX_USAGE)
+		}
+		authenticator, err := surfstore.NewAuthenticator(*usersFile, *certUsers, serverToken, serverNames)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not load users: ", err)
+			os.Exit(1)
+		}
+		serverOpts = append(serverOpts, grpc.UnaryInterceptor(authenticator.UnaryInterceptor), grpc.StreamInterceptor(authenticator.StreamInterceptor))
+	}
+
+	var blockBackend surfstore.BlockBackend
+	if serviceType == "block" || serviceType == "both" {
+		var err error
+		blockBackend, err = surfstore.NewBlockBackend(strings.ToLower(*backend), *blockDir)
+		if err != nil {
+			fmt.Fprintln(flag.CommandLine.Output(), err)
+			flag.Usage()
+			os.Exit(EX_USAGE)
+		}
+	}
+
+	var metaStore *surfstore.MetaStore
+	if serviceType == "meta" || serviceType == "both" {
+		metaStore = surfstore.NewMetaStore(blockStoreAddrs)
+		metaStore.ReplicationFactor = *replicas
+		metaStore.HistoryLimit = *history
+		metaStore.TrashRetention = *trash
+		metaStore.GCGracePeriod = *gcGrace
+		metaStore.DialOption = dialOpt
+		metaStore.Token = serverToken
+		metaStore.ConsistentHashRing = surfstore.NewWeightedConsistentHashRing(blockStoreAddrs, weights, *vnodes)
+		// with Raft the snapshot and log are the durable state and the
+		// MetaStore is rebuilt from them
+		if len(raftPeers) == 0 && *dataDir != "" {
+			if err := metaStore.Recover(*dataDir); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if *gcInterval > 0 {
+			metaStore.StartGarbageCollector(*gcInterval)
+		}
+	}
+
+	log.Fatal(startServer(addr, serviceType, metaStore, blockBackend, *dataDir, raftPeers, int64(*id), serverOpts...))
 }
 
-func startServer(hostAddr string, serviceType string, blockStoreAddrs []string) error {
+func startServer(hostAddr string, serviceType string, metaStore *surfstore.MetaStore, blockBackend surfstore.BlockBackend, dataDir string, raftPeers []string, id int64, opts ...grpc.ServerOption) error {
 	l, err := net.Listen("tcp", hostAddr)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
-	server := grpc.NewServer()
-
-	switch serviceType {
-	case "meta":
-		surfstore.RegisterMetaStoreServer(server, surfstore.NewMetaStore(blockStoreAddrs))
-	case "block":
-		surfstore.RegisterBlockStoreServer(server, surfstore.NewBlockStore())
-	case "both":
-		surfstore.RegisterMetaStoreServer(server, surfstore.NewMetaStore(blockStoreAddrs))
-		surfstore.RegisterBlockStoreServer(server, surfstore.NewBlockStore())
+	if len(raftPeers) > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(surfstore.RAFT_MAX_MSG_SIZE))
+	} else if serviceType == "block" || serviceType == "both" {
+		opts = append(opts, surfstore.BlockStoreServerOption())
+	}
+	server := grpc.NewServer(opts...)
+
+	if len(raftPeers) > 0 {
+		raftServer, err := surfstore.NewRaftSurfstore(id, raftPeers, metaStore, dataDir)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		surfstore.RegisterMetaStoreServer(server, raftServer)
+		surfstore.RegisterRaftSurfstoreServer(server, raftServer)
+		raftServer.Start()
+	} else if metaStore != nil {
+		surfstore.RegisterMetaStoreServer(server, metaStore)
+	}
+	if serviceType == "block" || serviceType == "both" {
+		surfstore.RegisterBlockStoreServer(server, surfstore.NewBlockStoreWithBackend(blockBackend))
 	}
 
 	if err := server.Serve(l); err != nil {
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"cse224/proj4/pkg/surfstore"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+// Usage strings
+const USAGE_STRING = "./run-share.sh -d -tls -ca file -cert file -key file -encrypt -convergent host:port list | grant path user read|write | revoke path user"
+
+const DEBUG_NAME = "d"
+const DEBUG_USAGE = "Output log statements"
+
+const ADDR_NAME = "host:port"
+const ADDR_USAGE = "IP address and port of the MetaStore (comma separated for a Raft cluster)"
+
+const COMMAND_NAME = "list | grant path user read|write | revoke path user"
+const COMMAND_USAGE = "List the folders shared by and with you, share a folder with a user, or stop sharing it"
+
+const TOKEN_USAGE = "Token identifying the user to the servers"
+
+// Permissions by name
+var PERMISSIONS = map[string]surfstore.Permission{"read": surfstore.Permission_READ, "write": surfstore.Permission_READ_WRITE}
+
+// Exit codes
+const EX_USAGE int = 64
+
+func main() {
+	// Flags shared by the client commands
+	tlsFlags := surfstore.RegisterClientTLSFlags(flag.CommandLine)
+	cipherFlags := surfstore.RegisterClientCipherFlags(flag.CommandLine)
+
+	// Custom flag Usage message
+	flag.Usage = func() {
+		w := flag.CommandLine.Output()
+		fmt.Fprintf(w, "Usage of %s:\n", USAGE_STRING)
+		fmt.Fprintf(w, "  -%s: %v\n", DEBUG_NAME, DEBUG_USAGE)
+		tlsFlags.PrintUsage(w)
+		cipherFlags.PrintUsage(w)
+		fmt.Fprintf(w, "  %s: %v\n", ADDR_NAME, ADDR_USAGE)
+		fmt.Fprintf(w, "  %s: %v\n", COMMAND_NAME, COMMAND_USAGE)
+		fmt.Fprintf(w, "  $%s: %v\n", surfstore.TOKEN_ENV, TOKEN_USAGE)
+	}
+
+	// Parse command-line arguments and flags
+	debug := flag.Bool("d", false, DEBUG_USAGE)
+	flag.Parse()
+
+	// Use tail arguments to hold non-flag arguments
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+	hostPort := args[0]
+	command := strings.ToLower(args[1])
+	switch {
+	case command == "list" && len(args) == 2:
+	case command == "grant" && len(args) == 5:
+		if _, ok := PERMISSIONS[strings.ToLower(args[4])]; !ok {
+			flag.Usage()
+			os.Exit(EX_USAGE)
+		}
+	case command == "revoke" && len(args) == 4:
+	default:
+		flag.Usage()
+		os.Exit(EX_USAGE)
+	}
+
+	dialOpt, err := tlsFlags.DialOption()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not set up TLS: ", err)
+		os.Exit(1)
+	}
+
+	cipher, err := cipherFlags.Cipher()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not derive encryption key: ", err)
+		os.Exit(EX_USAGE)
+	}
+
+	// Disable log outputs if debug flag is missing
+	if !(*debug) {
+		log.SetFlags(0)
+		log.SetOutput(ioutil.Discard)
+	}
+
+	rpcClient := surfstore.NewSurfstoreRPCClient(hostPort, "", 0)
+	rpcClient.DialOption = dialOpt
+	rpcClient.Token = os.Getenv(surfstore.TOKEN_ENV)
+	rpcClient.Cipher = cipher
+	defer rpcClient.Close()
+
+	if command == "list" {
+		var shares []*surfstore.Share
+		if err := rpcClient.GetShares(&shares); err != nil {
+			log.Fatal("[Surfstore RPCClient]:", "Error During Fetching Shares ", err)
+		}
+		PrintShares(shares)
+		return
+	}
+
+	path := strings.Trim(args[2], "/")
+	grantee := args[3]
+	var succ bool
+	if command == "grant" {
+		err = rpcClient.ShareFolder(path, grantee, PERMISSIONS[strings.ToLower(args[4])], &succ)
+	} else {
+		err = rpcClient.UnshareFolder(path, grantee, &succ)
+	}
+	if err != nil {
+		log.Fatal("[Surfstore RPCClient]:", "Error During Changing Shares ", err)
+	}
+	if !succ {
+		fmt.Println("Rejected: " + path)
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
+
+// One line per share: owner, path, who it is shared with and how
+func PrintShares(shares []*surfstore.Share) {
+	for _, share := range shares {
+		permission := "read"
+		if share.Permission == surfstore.Permission_READ_WRITE {
+			permission = "write"
+		}
+		fmt.Printf("%v\t%v\t%v\t%v\n", share.Owner, share.Path, share.Grantee, permission)
+	}
+}
@@ -0,0 +1,250 @@
+package surfstore
+
+import (
+	"bufio"
+	context "context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	status "google.golang.org/grpc/status"
+)
+
+// Request header carrying a user's token, as "Bearer <token>"
+const AUTH_HEADER string = "authorization"
+
+// Environment variable clients read their token from
+const TOKEN_ENV string = "SURFSTORE_TOKEN"
+
+// Environment variable holding the token servers present to each other,
+// which administrators present too. Servers accept it on SERVER_METHODS.
+const SERVER_TOKEN_ENV string = "SURFSTORE_SERVER_TOKEN"
+
+// Separates the user from the filename in the MetaStore's keys. It cannot
+// appear in a filename, so no name can reach into another namespace.
+const NAMESPACE_SEPARATOR string = "\x00"
+
+var ERR_UNAUTHENTICATED = status.Error(codes.Unauthenticated, "missing or invalid credentials")
+var ERR_SERVERS_ONLY = status.Error(codes.PermissionDenied, "only servers and administrators may call this method")
+var ERR_USERS_ONLY = status.Error(codes.PermissionDenied, "servers cannot act as a user")
+
+// Methods users may not call: Raft replication between MetaStores, changes
+// to the block servers on the ring, and listing or deleting blocks, which
+// belong to every user storing them
+var SERVER_METHODS = map[string]bool{
+	"/surfstore.RaftSurfstore/AppendEntries":   true,
+	"/surfstore.RaftSurfstore/RequestVote":     true,
+	"/surfstore.RaftSurfstore/InstallSnapshot": true,
+	"/surfstore.MetaStore/AddBlockStore":       true,
+	"/surfstore.MetaStore/RemoveBlockStore":    true,
+	"/surfstore.BlockStore/GetBlockHashes":     true,
+	"/surfstore.BlockStore/ListBlockHashes":    true,
+	"/surfstore.BlockStore/DeleteBlocks":       true,
+}
+
+// Servers may call SERVER_METHODS, every block server method, since blocks
+// are not namespaced, and the MetaStore methods locating block servers.
+// Everything else acts in a user's namespace, so servers cannot call it.
+func allowedForServers(method string) bool {
+	return SERVER_METHODS[method] || strings.HasPrefix(method, "/surfstore.BlockStore/") ||
+		method == "/surfstore.MetaStore/GetBlockStoreAddrs" || method == "/surfstore.MetaStore/GetBlockStoreMap"
+}
+
+type userContextKey struct{}
+
+// An Authenticator identifies the user behind every call to a server, by
+// the token in the call's metadata or, with TrustClientCerts, by the common
+// name of the client certificate verified by mutual TLS. Calls it cannot
+// identify are rejected.
+//
+// Servers and administrators identify themselves separately, with the
+// server token or a client certificate whose common name is in
+// ServerNames. Only they may call SERVER_METHODS, and they cannot act as a
+// user.
+type Authenticator struct {
+	TrustClientCerts bool
+	ServerNames      map[string]bool
+
+	// user of each token, keyed by the token's hash
+	tokens map[[sha256.Size]byte]string
+	// hash of the server token, if there is one
+	serverToken *[sha256.Size]byte
+}
+
+// NewAuthenticator reads the users allowed to connect from usersFile, one
+// "user token" pair per line; blank lines and lines starting with # are
+// skipped. usersFile may be empty if only client certificates are trusted.
+// Servers present serverToken, unless it is empty, or a certificate named
+// in serverNames.
+func NewAuthenticator(usersFile string, trustClientCerts bool, serverToken string, serverNames []string) (*Authenticator, error) {
+	a := &Authenticator{TrustClientCerts: trustClientCerts, ServerNames: map[string]bool{}, tokens: map[[sha256.Size]byte]string{}}
+	if serverToken != "" {
+		hash := sha256.Sum256([]byte(serverToken))
+		a.serverToken = &hash
+	}
+	for _, name := range serverNames {
+		a.ServerNames[name] = true
+	}
+	if usersFile == "" {
+		return a, nil
+	}
+	file, err := os.Open(usersFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !isValidUser(fields[0]) {
+			return nil, fmt.Errorf("%v:%v: expected \"user token\"", usersFile, lineNo)
+		}
+		hash := sha256.Sum256([]byte(fields[1]))
+		if a.serverToken != nil && hash == *a.serverToken {
+			return nil, fmt.Errorf("%v:%v: the server token cannot be a user's token", usersFile, lineNo)
+		}
+		a.tokens[hash] = fields[0]
+	}
+	return a, scanner.Err()
+}
+
+// User names end up in filenames as SHARED_DIR/<owner>/<path>, so they
+// cannot contain "/", nor "@", which SHARED_DIR starts with
+func isValidUser(user string) bool {
+	return user != "" && !strings.ContainsAny(user, NAMESPACE_SEPARATOR+"/@")
+}
+
+// Find who makes the call to method: a server, or else a user. The
+// context to handle the call with carries the user.
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	tokens := []string{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, header := range md.Get(AUTH_HEADER) {
+			tokens = append(tokens, strings.TrimPrefix(header, "Bearer "))
+		}
+	}
+	commonName := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+			commonName = tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+		}
+	}
+
+	server := commonName != "" && a.ServerNames[commonName]
+	for _, token := range tokens {
+		if a.serverToken != nil && sha256.Sum256([]byte(token)) == *a.serverToken {
+			server = true
+		}
+	}
+	if server {
+		if !allowedForServers(method) {
+			return nil, ERR_USERS_ONLY
+		}
+		return ctx, nil
+	}
+
+	user := ""
+	for _, token := range tokens {
+		if tokenUser, ok := a.tokens[sha256.Sum256([]byte(token))]; ok {
+			user = tokenUser
+			break
+		}
+	}
+	if user == "" && a.TrustClientCerts && isValidUser(commonName) {
+		user = commonName
+	}
+	if user == "" {
+		return nil, ERR_UNAUTHENTICATED
+	}
+	if SERVER_METHODS[method] {
+		return nil, ERR_SERVERS_ONLY
+	}
+	return context.WithValue(ctx, userContextKey{}, user), nil
+}
+
+func (a *Authenticator) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *Authenticator) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ss, ctx})
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// The user an Authenticator found for the call, or "" if the server does
+// not authenticate
+func UserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userContextKey{}).(string)
+	return user
+}
+
+// Key of filename in user's namespace in the MetaStore. Without
+// authentication everything lives in the namespace of user "", whose keys
+// are the plain filenames.
+func namespacedName(user string, filename string) string {
+	if user == "" {
+		return filename
+	}
+	return user + NAMESPACE_SEPARATOR + filename
+}
+
+// Split a MetaStore key into its user and filename
+func splitNamespacedName(key string) (user string, filename string) {
+	if i := strings.Index(key, NAMESPACE_SEPARATOR); i >= 0 {
+		return key[:i], key[i+len(NAMESPACE_SEPARATOR):]
+	}
+	return "", key
+}
+
+/* Client side */
+
+// Attaches a token to every call
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{AUTH_HEADER: "Bearer " + string(t)}, nil
+}
+
+// Tokens are also sent over plaintext connections; use TLS to protect them
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// Options to connect with: the transport (plaintext unless set), the token,
+// if any, and a receive limit fitting blocks of MAX_BLOCK_SIZE
+func dialOptions(transport grpc.DialOption, token string) []grpc.DialOption {
+	if transport == nil {
+		transport = grpc.WithInsecure()
+	}
+	opts := []grpc.DialOption{transport, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(BLOCK_MAX_MSG_SIZE))}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(token)))
+	}
+	return opts
+}
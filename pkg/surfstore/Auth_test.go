@@ -0,0 +1,95 @@
+package surfstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthenticatorServerMethods(t *testing.T) {
+	usersFile := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(usersFile, []byte("alice alice-token\nbob bob-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := NewAuthenticator(usersFile, false, "server-token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		code   codes.Code
+		user   string
+	}{
+		{"user updates a file", "alice-token", "/surfstore.MetaStore/UpdateFile", codes.OK, "alice"},
+		{"user reads a block", "bob-token", "/surfstore.BlockStore/GetBlock", codes.OK, "bob"},
+		{"user appends entries", "alice-token", "/surfstore.RaftSurfstore/AppendEntries", codes.PermissionDenied, ""},
+		{"user installs a snapshot", "alice-token", "/surfstore.RaftSurfstore/InstallSnapshot", codes.PermissionDenied, ""},
+		{"user deletes blocks", "alice-token", "/surfstore.BlockStore/DeleteBlocks", codes.PermissionDenied, ""},
+		{"user lists blocks", "bob-token", "/surfstore.BlockStore/ListBlockHashes", codes.PermissionDenied, ""},
+		{"user removes a block store", "bob-token", "/surfstore.MetaStore/RemoveBlockStore", codes.PermissionDenied, ""},
+		{"server appends entries", "server-token", "/surfstore.RaftSurfstore/AppendEntries", codes.OK, ""},
+		{"server deletes blocks", "server-token", "/surfstore.BlockStore/DeleteBlocks", codes.OK, ""},
+		{"server copies a block", "server-token", "/surfstore.BlockStore/PutBlock", codes.OK, ""},
+		{"server locates block stores", "server-token", "/surfstore.MetaStore/GetBlockStoreAddrs", codes.OK, ""},
+		{"server updates a file", "server-token", "/surfstore.MetaStore/UpdateFile", codes.PermissionDenied, ""},
+		{"unknown token", "mallory-token", "/surfstore.MetaStore/UpdateFile", codes.Unauthenticated, ""},
+		{"no token", "", "/surfstore.RaftSurfstore/RequestVote", codes.Unauthenticated, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(AUTH_HEADER, "Bearer "+test.token))
+			}
+			ctx, err := authenticator.authenticate(ctx, test.method)
+			if status.Code(err) != test.code {
+				t.Fatalf("got %v, expected %v", err, test.code)
+			}
+			if err == nil && UserFromContext(ctx) != test.user {
+				t.Errorf("call made as %q, expected %q", UserFromContext(ctx), test.user)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorRejectsServerTokenAsUser(t *testing.T) {
+	usersFile := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(usersFile, []byte("alice server-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAuthenticator(usersFile, false, "server-token", nil); err == nil {
+		t.Error("accepted a user with the server token")
+	}
+}
+
+func TestAuthenticatorUserNames(t *testing.T) {
+	tests := []struct {
+		user string
+		ok   bool
+	}{
+		{"alice", true},
+		{"alice.smith", true},
+		{"a/b", false},
+		{"@shared", false},
+		{"al\x00ice", false},
+	}
+	for _, test := range tests {
+		t.Run(test.user, func(t *testing.T) {
+			usersFile := filepath.Join(t.TempDir(), "users")
+			if err := os.WriteFile(usersFile, []byte(test.user+" token\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewAuthenticator(usersFile, false, "server-token", nil); (err == nil) != test.ok {
+				t.Errorf("loading user %q: %v", test.user, err)
+			}
+		})
+	}
+}
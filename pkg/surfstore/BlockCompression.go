@@ -0,0 +1,128 @@
+package surfstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	context "context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/golang/snappy"
+	"google.golang.org/grpc/metadata"
+)
+
+// Request header listing the compressions a client can decode, e.g.
+// "gzip,snappy". A BlockStore serves a compressed block as stored if the
+// client accepts its compression, and decompresses it otherwise.
+const ACCEPT_COMPRESSION_HEADER string = "surfstore-accept-compression"
+
+// Largest message block servers and clients receive. Blocks are sent in
+// single messages, and a block of MAX_BLOCK_SIZE exceeds gRPC's 4 MB default
+// once its hash, the protobuf framing and an encryption nonce and tag are
+// added.
+const BLOCK_MAX_MSG_SIZE int = MAX_BLOCK_SIZE + 64<<10
+
+// Compressions this package can decode, i.e. every one but NONE
+var supportedCompressions = []Compression{Compression_GZIP, Compression_SNAPPY}
+
+// ParseCompression looks up a compression by its lowercase name
+func ParseCompression(name string) (Compression, error) {
+	if value, ok := Compression_value[strings.ToUpper(name)]; ok {
+		return Compression(value), nil
+	}
+	return Compression_NONE, fmt.Errorf("unknown compression: %v", name)
+}
+
+// Compress a block's data with c. If that does not make it smaller, as for
+// already compressed content, the block is returned as is.
+func compressBlock(block *Block, c Compression) (*Block, error) {
+	if c == Compression_NONE || block.Compression != Compression_NONE {
+		return block, nil
+	}
+	var data []byte
+	switch c {
+	case Compression_GZIP:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(block.BlockData); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	case Compression_SNAPPY:
+		data = snappy.Encode(nil, block.BlockData)
+	default:
+		return nil, fmt.Errorf("unknown compression: %v", c)
+	}
+	if len(data) >= len(block.BlockData) {
+		return block, nil
+	}
+	return &Block{BlockData: data, BlockSize: int32(len(data)), Compression: c}, nil
+}
+
+// Return the uncompressed form of a block
+func decompressBlock(block *Block) (*Block, error) {
+	var data []byte
+	switch block.Compression {
+	case Compression_NONE:
+		return block, nil
+	case Compression_GZIP:
+		r, err := gzip.NewReader(bytes.NewReader(block.BlockData))
+		if err != nil {
+			return nil, err
+		}
+		// a few bytes of gzip may expand to gigabytes
+		if data, err = ioutil.ReadAll(io.LimitReader(r, int64(MAX_BLOCK_SIZE)+1)); err != nil {
+			return nil, err
+		}
+		if len(data) > MAX_BLOCK_SIZE {
+			return nil, fmt.Errorf("block decompresses to more than %v bytes", MAX_BLOCK_SIZE)
+		}
+	case Compression_SNAPPY:
+		size, err := snappy.DecodedLen(block.BlockData)
+		if err != nil {
+			return nil, err
+		}
+		if size > MAX_BLOCK_SIZE {
+			return nil, fmt.Errorf("block decompresses to more than %v bytes", MAX_BLOCK_SIZE)
+		}
+		if data, err = snappy.Decode(nil, block.BlockData); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression: %v", block.Compression)
+	}
+	return &Block{BlockData: data, BlockSize: int32(len(data))}, nil
+}
+
+// Tell the BlockStore every compression we can decode
+func withAcceptCompression(ctx context.Context) context.Context {
+	names := []string{}
+	for _, c := range supportedCompressions {
+		names = append(names, strings.ToLower(c.String()))
+	}
+	return metadata.AppendToOutgoingContext(ctx, ACCEPT_COMPRESSION_HEADER, strings.Join(names, ","))
+}
+
+// Whether the client that sent ctx accepts blocks compressed with c
+func acceptsCompression(ctx context.Context, c Compression) bool {
+	if c == Compression_NONE {
+		return true
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, header := range md.Get(ACCEPT_COMPRESSION_HEADER) {
+		for _, name := range strings.Split(header, ",") {
+			if strings.TrimSpace(name) == strings.ToLower(c.String()) {
+				return true
+			}
+		}
+	}
+	return false
+}
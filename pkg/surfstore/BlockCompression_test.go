@@ -0,0 +1,95 @@
+package surfstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	grpc "google.golang.org/grpc"
+)
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressBlockSize(t *testing.T) {
+	largest := make([]byte, MAX_BLOCK_SIZE)
+	tooLarge := make([]byte, MAX_BLOCK_SIZE+1)
+	tests := []struct {
+		name  string
+		block *Block
+		size  int
+		ok    bool
+	}{
+		{"gzip", &Block{BlockData: gzipData(t, largest), Compression: Compression_GZIP}, MAX_BLOCK_SIZE, true},
+		{"gzip bomb", &Block{BlockData: gzipData(t, tooLarge), Compression: Compression_GZIP}, 0, false},
+		{"snappy", &Block{BlockData: snappy.Encode(nil, largest), Compression: Compression_SNAPPY}, MAX_BLOCK_SIZE, true},
+		{"snappy bomb", &Block{BlockData: snappy.Encode(nil, tooLarge), Compression: Compression_SNAPPY}, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			block, err := decompressBlock(test.block)
+			if (err == nil) != test.ok {
+				t.Fatalf("decompressing %v compressed bytes: %v", len(test.block.BlockData), err)
+			}
+			if test.ok && (len(block.BlockData) != test.size || block.Compression != Compression_NONE) {
+				t.Errorf("decompressed to %v bytes, expected %v", len(block.BlockData), test.size)
+			}
+		})
+	}
+}
+
+func TestLargestBlockRoundTrip(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(BlockStoreServerOption())
+	RegisterBlockStoreServer(server, NewBlockStore())
+	go server.Serve(l)
+	defer server.Stop()
+	addr := l.Addr().String()
+
+	client := NewSurfstoreRPCClient(addr, t.TempDir(), MAX_BLOCK_SIZE)
+	defer client.Close()
+	client.Timeouts[""] = 10 * time.Second
+	cipher, err := NewCipher("passphrase", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// random data does not compress, and encryption adds its nonce and tag
+	data := make([]byte, MAX_BLOCK_SIZE)
+	rand.New(rand.NewSource(1)).Read(data)
+	unary := cipher.EncryptBlock("unary", data)
+	streamed := cipher.EncryptBlock("streamed", data)
+
+	var succ bool
+	if err := client.PutBlock(&Block{BlockData: unary, BlockSize: int32(len(unary))}, addr, &succ); err != nil || !succ {
+		t.Fatalf("PutBlock of %v bytes: %v", len(unary), err)
+	}
+	var block Block
+	if err := client.GetBlock(GetBlockHashString(unary), addr, &block); err != nil || !bytes.Equal(block.BlockData, unary) {
+		t.Fatalf("GetBlock of %v bytes: %v", len(unary), err)
+	}
+
+	if err := client.PutBlocks([]*Block{{BlockData: streamed, BlockSize: int32(len(streamed))}}, addr, &succ); err != nil || !succ {
+		t.Fatalf("PutBlocks of %v bytes: %v", len(streamed), err)
+	}
+	var blocks []*Block
+	if err := client.GetBlocks([]string{GetBlockHashString(streamed)}, addr, &blocks); err != nil || len(blocks) != 1 || !bytes.Equal(blocks[0].BlockData, streamed) {
+		t.Fatalf("GetBlocks of %v bytes: %v", len(streamed), err)
+	}
+}
@@ -2,42 +2,130 @@ package surfstore
 
 import (
 	context "context"
-	"fmt"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	"io"
 	sync "sync"
+	"time"
 )
 
+// Number of hashes in each message of a ListBlockHashes stream; at 64
+// characters per hash a page stays far below the gRPC message size limit
+const BLOCK_HASHES_PAGE_SIZE int = 1000
+
+// The BlockStore remembers when each block was last written or confirmed to
+// a client by HasBlocks, so that DeleteBlocks can spare blocks a client may
+// be about to reference. Blocks not touched since startup count as touched
+// at startup.
 type BlockStore struct {
-	BlockMap map[string]*Block
-	mtx      sync.Mutex
+	Backend BlockBackend
+
+	// held for writing while deleting, so a block cannot be put or
+	// confirmed between the grace period check and its deletion
+	deleteMtx sync.RWMutex
+	touchMtx  sync.Mutex
+	touched   map[string]time.Time
+	started   time.Time
 	UnimplementedBlockStoreServer
 }
 
 func (bs *BlockStore) GetBlock(ctx context.Context, blockHash *BlockHash) (*Block, error) {
-	bs.mtx.Lock()
-	defer bs.mtx.Unlock()
-	if _, ok := bs.BlockMap[blockHash.Hash]; !ok {
-		return nil, fmt.Errorf("block not found: %v", blockHash.Hash)
+	block, ok, err := bs.Backend.Get(blockHash.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "block not found: %v", blockHash.Hash)
 	}
-	return bs.BlockMap[blockHash.Hash], nil
+	if !acceptsCompression(ctx, block.Compression) {
+		return decompressBlock(block)
+	}
+	return block, nil
 }
 
+// A compressed block is stored compressed; its hash is still the hash of
+// the uncompressed data.
 func (bs *BlockStore) PutBlock(ctx context.Context, block *Block) (*Success, error) {
-	hash := GetBlockHashString(block.BlockData)
-	bs.mtx.Lock()
-	defer bs.mtx.Unlock()
-	bs.BlockMap[hash] = block
+	uncompressed, err := decompressBlock(block)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid block: %v", err)
+	}
+	hash := GetBlockHashString(uncompressed.BlockData)
+	bs.deleteMtx.RLock()
+	defer bs.deleteMtx.RUnlock()
+	if err := bs.Backend.Put(hash, block); err != nil {
+		return nil, err
+	}
+	bs.touch(hash)
 	return &Success{Flag: true}, nil
 }
 
+// Store every block sent on the stream. Blocks are stored as they arrive,
+// so if the stream breaks the blocks received so far are kept.
+func (bs *BlockStore) PutBlocks(stream BlockStore_PutBlocksServer) error {
+	for {
+		block, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&Success{Flag: true})
+		} else if err != nil {
+			return err
+		}
+		if _, err := bs.PutBlock(stream.Context(), block); err != nil {
+			return err
+		}
+	}
+}
+
+// Stream the requested blocks back in order
+func (bs *BlockStore) GetBlocks(blockHashes *BlockHashes, stream BlockStore_GetBlocksServer) error {
+	for _, hash := range blockHashes.Hashes {
+		block, err := bs.GetBlock(stream.Context(), &BlockHash{Hash: hash})
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Answer each hash received with its block, letting the client keep asking
+// for blocks while earlier ones are still in flight
+func (bs *BlockStore) GetBlocksStream(stream BlockStore_GetBlocksStreamServer) error {
+	for {
+		blockHash, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		block, err := bs.GetBlock(stream.Context(), blockHash)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(block); err != nil {
+			return err
+		}
+	}
+}
+
 // Given a list of hashes “in”, returns a list containing the
 // subset of in that are stored in the key-value store
 func (bs *BlockStore) HasBlocks(ctx context.Context, blockHashesIn *BlockHashes) (*BlockHashes, error) {
 	var hashes []string
-	bs.mtx.Lock()
-	defer bs.mtx.Unlock()
+	bs.deleteMtx.RLock()
+	defer bs.deleteMtx.RUnlock()
 	for _, hash := range blockHashesIn.Hashes {
-		if _, ok := bs.BlockMap[hash]; ok {
+		ok, err := bs.Backend.Has(hash)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			// the client will rely on this block instead of uploading it
+			bs.touch(hash)
 			hashes = append(hashes, hash)
 		}
 	}
@@ -48,18 +136,91 @@ func (bs *BlockStore) HasBlocks(ctx context.Context, blockHashesIn *BlockHashes)
 var _ BlockStoreInterface = new(BlockStore)
 
 func NewBlockStore() *BlockStore {
+	return NewBlockStoreWithBackend(NewMemoryBackend())
+}
+
+func NewBlockStoreWithBackend(backend BlockBackend) *BlockStore {
 	return &BlockStore{
-		BlockMap: map[string]*Block{},
+		Backend: backend,
+		touched: map[string]time.Time{},
+		started: time.Now(),
 	}
 }
 
+// Server option letting a BlockStore receive blocks of MAX_BLOCK_SIZE
+func BlockStoreServerOption() grpc.ServerOption {
+	return grpc.MaxRecvMsgSize(BLOCK_MAX_MSG_SIZE)
+}
+
 // Return a list containing all blockHashes on this block server
 func (bs *BlockStore) GetBlockHashes(ctx context.Context, _ *emptypb.Empty) (*BlockHashes, error) {
-	hashes := make([]string, 0, len(bs.BlockMap))
-	bs.mtx.Lock()
-	defer bs.mtx.Unlock()
-	for k := range bs.BlockMap {
-		hashes = append(hashes, k)
+	hashes, err := bs.Backend.Hashes()
+	if err != nil {
+		return nil, err
+	}
+	return &BlockHashes{Hashes: hashes}, nil
+}
+
+// Stream back all blockHashes on this block server, BLOCK_HASHES_PAGE_SIZE
+// at a time, so a listing is never too large for a single message
+func (bs *BlockStore) ListBlockHashes(_ *emptypb.Empty, stream BlockStore_ListBlockHashesServer) error {
+	hashes, err := bs.Backend.Hashes()
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(hashes); start += BLOCK_HASHES_PAGE_SIZE {
+		end := start + BLOCK_HASHES_PAGE_SIZE
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		if err := stream.Send(&BlockHashes{Hashes: hashes[start:end]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete the given blocks, except those written or confirmed by HasBlocks
+// within the grace period. Returns the hashes that were deleted.
+func (bs *BlockStore) DeleteBlocks(ctx context.Context, input *DeleteBlocksInput) (*BlockHashes, error) {
+	gracePeriod := time.Duration(input.GracePeriodMs) * time.Millisecond
+	bs.deleteMtx.Lock()
+	defer bs.deleteMtx.Unlock()
+
+	var hashes []string
+	for _, hash := range input.Hashes {
+		if time.Since(bs.lastTouched(hash)) < gracePeriod {
+			continue
+		}
+		ok, err := bs.Backend.Has(hash)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if err := bs.Backend.Delete(hash); err != nil {
+			return nil, err
+		}
+		bs.touchMtx.Lock()
+		delete(bs.touched, hash)
+		bs.touchMtx.Unlock()
+		hashes = append(hashes, hash)
 	}
 	return &BlockHashes{Hashes: hashes}, nil
 }
+
+func (bs *BlockStore) touch(hash string) {
+	bs.touchMtx.Lock()
+	defer bs.touchMtx.Unlock()
+	bs.touched[hash] = time.Now()
+}
+
+func (bs *BlockStore) lastTouched(hash string) time.Time {
+	bs.touchMtx.Lock()
+	defer bs.touchMtx.Unlock()
+	if t, ok := bs.touched[hash]; ok {
+		return t
+	}
+	return bs.started
+}
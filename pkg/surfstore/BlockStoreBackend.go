@@ -0,0 +1,300 @@
+package surfstore
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	sync "sync"
+)
+
+// A BlockBackend is the storage layer underneath a BlockStore server.
+// Implementations must be safe for concurrent use.
+type BlockBackend interface {
+	// Get the block stored under hash, reporting whether it exists
+	Get(hash string) (*Block, bool, error)
+
+	// Store a block under its hash
+	Put(hash string, block *Block) error
+
+	// Report whether a block is stored under hash
+	Has(hash string) (bool, error)
+
+	// List the hashes of all stored blocks
+	Hashes() ([]string, error)
+
+	// Remove the block stored under hash, if any
+	Delete(hash string) error
+}
+
+const (
+	MEMORY_BACKEND string = "memory"
+	DISK_BACKEND   string = "disk"
+)
+
+// NewBlockBackend builds the backend named by kind. dir is only used by
+// the disk backend.
+func NewBlockBackend(kind string, dir string) (BlockBackend, error) {
+	switch kind {
+	case MEMORY_BACKEND:
+		return NewMemoryBackend(), nil
+	case DISK_BACKEND:
+		return NewDiskBackend(dir)
+	}
+	return nil, fmt.Errorf("unknown block backend: %v", kind)
+}
+
+/* Memory Backend */
+
+// MemoryBackend keeps every block in a map. Blocks are lost on restart.
+type MemoryBackend struct {
+	BlockMap map[string]*Block
+	mtx      sync.RWMutex
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		BlockMap: map[string]*Block{},
+	}
+}
+
+func (mb *MemoryBackend) Get(hash string) (*Block, bool, error) {
+	mb.mtx.RLock()
+	defer mb.mtx.RUnlock()
+	block, ok := mb.BlockMap[hash]
+	return block, ok, nil
+}
+
+func (mb *MemoryBackend) Put(hash string, block *Block) error {
+	mb.mtx.Lock()
+	defer mb.mtx.Unlock()
+	mb.BlockMap[hash] = block
+	return nil
+}
+
+func (mb *MemoryBackend) Has(hash string) (bool, error) {
+	mb.mtx.RLock()
+	defer mb.mtx.RUnlock()
+	_, ok := mb.BlockMap[hash]
+	return ok, nil
+}
+
+func (mb *MemoryBackend) Hashes() ([]string, error) {
+	mb.mtx.RLock()
+	defer mb.mtx.RUnlock()
+	hashes := make([]string, 0, len(mb.BlockMap))
+	for k := range mb.BlockMap {
+		hashes = append(hashes, k)
+	}
+	return hashes, nil
+}
+
+func (mb *MemoryBackend) Delete(hash string) error {
+	mb.mtx.Lock()
+	defer mb.mtx.Unlock()
+	delete(mb.BlockMap, hash)
+	return nil
+}
+
+var _ BlockBackend = new(MemoryBackend)
+
+/* Disk Backend */
+
+// DiskBackend stores each block in its own file, content-addressed by hash.
+// Blocks are fanned out into subdirectories named after the first two
+// characters of the hash, e.g. <dir>/ab/abcdef... A compressed block is
+// stored as is, with the compression as extension, e.g. <dir>/ab/abcdef.gzip
+type DiskBackend struct {
+	Dir string
+
+	// held while a block's files change, so that concurrent puts of one
+	// block with different compressions cannot remove each other's copy;
+	// a block uses the lock of the first byte of its hash
+	locks [256]sync.RWMutex
+}
+
+func NewDiskBackend(dir string) (*DiskBackend, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskBackend{Dir: dir}, nil
+}
+
+func (db *DiskBackend) blockPath(hash string) (string, error) {
+	// hashes come from clients, so never let one escape the block directory
+	if !isValidBlockHash(hash) {
+		return "", fmt.Errorf("invalid block hash: %q", hash)
+	}
+	return filepath.Join(db.Dir, hash[:2], hash), nil
+}
+
+// Whether hash is a SHA-256 hash as GetBlockHashString formats it: lowercase
+// hex of the expected length
+func isValidBlockHash(hash string) bool {
+	if len(hash) != 2*sha256.Size {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Every file a block may be stored in, one per compression
+func (db *DiskBackend) blockPaths(hash string) (map[Compression]string, error) {
+	path, err := db.blockPath(hash)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[Compression]string{Compression_NONE: path}
+	for _, c := range supportedCompressions {
+		paths[c] = path + compressionExt(c)
+	}
+	return paths, nil
+}
+
+// Compressions a block may be stored with, in the order Get looks for them.
+// A crash between writing a block and removing its copies under another
+// compression leaves several, and the uncompressed one is served first.
+var storedCompressions = append([]Compression{Compression_NONE}, supportedCompressions...)
+
+// Lock guarding the files of a valid block hash
+func (db *DiskBackend) lock(hash string) *sync.RWMutex {
+	b, _ := strconv.ParseUint(hash[:2], 16, 8)
+	return &db.locks[b]
+}
+
+func compressionExt(c Compression) string {
+	return "." + strings.ToLower(c.String())
+}
+
+func (db *DiskBackend) Get(hash string) (*Block, bool, error) {
+	paths, err := db.blockPaths(hash)
+	if err != nil {
+		return nil, false, nil
+	}
+	mtx := db.lock(hash)
+	mtx.RLock()
+	defer mtx.RUnlock()
+	for _, c := range storedCompressions {
+		data, err := ioutil.ReadFile(paths[c])
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, false, err
+		}
+		return &Block{BlockData: data, BlockSize: int32(len(data)), Compression: c}, true, nil
+	}
+	return nil, false, nil
+}
+
+// Put writes the block to a temporary file and renames it into place, so a
+// crash never leaves a partially written block under its final name. Copies
+// stored with another compression are removed afterwards.
+func (db *DiskBackend) Put(hash string, block *Block) error {
+	paths, err := db.blockPaths(hash)
+	if err != nil {
+		return err
+	}
+	path := paths[block.Compression]
+	if path == "" {
+		return fmt.Errorf("unknown compression: %v", block.Compression)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	mtx := db.lock(hash)
+	mtx.Lock()
+	defer mtx.Unlock()
+	if err := writeFileAtomic(path, block.BlockData); err != nil {
+		return err
+	}
+	for c, other := range paths {
+		if c != block.Compression {
+			if err := os.Remove(other); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (db *DiskBackend) Has(hash string) (bool, error) {
+	paths, err := db.blockPaths(hash)
+	if err != nil {
+		return false, nil
+	}
+	mtx := db.lock(hash)
+	mtx.RLock()
+	defer mtx.RUnlock()
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (db *DiskBackend) Hashes() ([]string, error) {
+	known := map[string]bool{"": true}
+	for _, c := range supportedCompressions {
+		known[compressionExt(c)] = true
+	}
+	seen := map[string]bool{}
+	hashes := []string{}
+	dirs, err := ioutil.ReadDir(db.Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(db.Dir, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			// anything else is a temporary file
+			ext := filepath.Ext(file.Name())
+			if file.IsDir() || !known[ext] {
+				continue
+			}
+			hash := strings.TrimSuffix(file.Name(), ext)
+			if !isValidBlockHash(hash) {
+				continue
+			}
+			if !seen[hash] {
+				seen[hash] = true
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	return hashes, nil
+}
+
+func (db *DiskBackend) Delete(hash string) error {
+	paths, err := db.blockPaths(hash)
+	if err != nil {
+		return nil
+	}
+	mtx := db.lock(hash)
+	mtx.Lock()
+	defer mtx.Unlock()
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ BlockBackend = new(DiskBackend)
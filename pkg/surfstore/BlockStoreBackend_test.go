@@ -0,0 +1,155 @@
+package surfstore
+
+import (
+	"bytes"
+	context "context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	sync "sync"
+	"testing"
+
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestBlockBackends(t *testing.T) {
+	plain := []byte("plain block")
+	compressible := bytes.Repeat([]byte("compressible block "), 100)
+	gzipped, err := compressBlock(&Block{BlockData: compressible, BlockSize: int32(len(compressible))}, Compression_GZIP)
+	if err != nil || gzipped.Compression != Compression_GZIP {
+		t.Fatalf("compressing block: %v", err)
+	}
+	stored := map[string][]byte{
+		GetBlockHashString(plain):        plain,
+		GetBlockHashString(compressible): compressible,
+	}
+	missing := GetBlockHashString([]byte("missing block"))
+
+	tests := []struct {
+		name    string
+		kind    string
+		restart bool
+	}{
+		{"memory", MEMORY_BACKEND, false},
+		{"disk", DISK_BACKEND, false},
+		{"disk after restart", DISK_BACKEND, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			backend, err := NewBlockBackend(test.kind, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bs := NewBlockStoreWithBackend(backend)
+			for _, block := range []*Block{{BlockData: plain, BlockSize: int32(len(plain))}, gzipped} {
+				if s, err := bs.PutBlock(ctx, block); err != nil || !s.Flag {
+					t.Fatalf("PutBlock: %v", err)
+				}
+			}
+			if test.restart {
+				if backend, err = NewBlockBackend(test.kind, dir); err != nil {
+					t.Fatal(err)
+				}
+				bs = NewBlockStoreWithBackend(backend)
+			}
+
+			for hash, data := range stored {
+				block, err := bs.GetBlock(ctx, &BlockHash{Hash: hash})
+				if err != nil || !bytes.Equal(block.BlockData, data) || block.Compression != Compression_NONE {
+					t.Errorf("GetBlock(%v) = %v, %v", hash, block, err)
+				}
+			}
+			for _, hash := range []string{missing, "../" + missing} {
+				if _, err := bs.GetBlock(ctx, &BlockHash{Hash: hash}); err == nil {
+					t.Errorf("GetBlock(%q) succeeded", hash)
+				}
+			}
+
+			has, err := bs.HasBlocks(ctx, &BlockHashes{Hashes: []string{GetBlockHashString(plain), missing, GetBlockHashString(compressible)}})
+			if err != nil || len(has.Hashes) != 2 || has.Hashes[0] != GetBlockHashString(plain) || has.Hashes[1] != GetBlockHashString(compressible) {
+				t.Errorf("HasBlocks = %v, %v", has, err)
+			}
+
+			hashes, err := bs.GetBlockHashes(ctx, &emptypb.Empty{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []string{GetBlockHashString(plain), GetBlockHashString(compressible)}
+			sort.Strings(hashes.Hashes)
+			sort.Strings(expected)
+			if len(hashes.Hashes) != 2 || hashes.Hashes[0] != expected[0] || hashes.Hashes[1] != expected[1] {
+				t.Errorf("GetBlockHashes = %v, expected %v", hashes.Hashes, expected)
+			}
+		})
+	}
+}
+
+func TestDiskBackendPrefersUncompressedCopy(t *testing.T) {
+	backend, err := NewDiskBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("block "), 100)
+	hash := GetBlockHashString(data)
+	gzipped, err := compressBlock(&Block{BlockData: data, BlockSize: int32(len(data))}, Compression_GZIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// as left by a crash while replacing the gzip copy with an uncompressed one
+	if err := backend.Put(hash, gzipped); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(backend.Dir, hash[:2], hash)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + compressionExt(Compression_GZIP)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		block, ok, err := backend.Get(hash)
+		if err != nil || !ok || block.Compression != Compression_NONE || !bytes.Equal(block.BlockData, data) {
+			t.Fatalf("Get served %v, %v, %v", block, ok, err)
+		}
+	}
+}
+
+func TestDiskBackendConcurrentMixedCompressions(t *testing.T) {
+	backend, err := NewDiskBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := NewBlockStoreWithBackend(backend)
+	for i := 0; i < 100; i++ {
+		data := bytes.Repeat([]byte(fmt.Sprintf("block %v ", i)), 100)
+		hash := GetBlockHashString(data)
+		gzipped, err := compressBlock(&Block{BlockData: data, BlockSize: int32(len(data))}, Compression_GZIP)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// two clients upload the same block, one compressed and one not
+		var wg sync.WaitGroup
+		for _, block := range []*Block{gzipped, {BlockData: data, BlockSize: int32(len(data))}} {
+			wg.Add(1)
+			go func(block *Block) {
+				defer wg.Done()
+				if s, err := bs.PutBlock(context.Background(), block); err != nil || !s.Flag {
+					t.Errorf("PutBlock: %v", err)
+				}
+			}(block)
+		}
+		wg.Wait()
+
+		block, err := bs.GetBlock(context.Background(), &BlockHash{Hash: hash})
+		if err != nil || !bytes.Equal(block.BlockData, data) {
+			t.Fatalf("block %v lost after concurrent puts: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package surfstore
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+const (
+	FIXED_CHUNKING string = "fixed"
+	CDC_CHUNKING   string = "cdc"
+)
+
+// Largest block a chunker produces; a compressed block that expands beyond
+// it is refused instead of decompressed.
+const MAX_BLOCK_SIZE int = 4 << 20
+
+// A Chunker splits a file into the blocks that are hashed and stored. Every
+// client syncing with the same server must split files the same way, or
+// they will see each other's files as changed.
+type Chunker interface {
+	// Call emit with each block read from r, in order. Blocks are never
+	// empty and emit may keep them.
+	Chunks(r io.Reader, emit func(block []byte) error) error
+}
+
+// NewChunker builds the chunker named by kind. Fixed chunking splits files
+// every avgSize bytes; content-defined chunking uses all three sizes.
+func NewChunker(kind string, minSize, avgSize, maxSize int) (Chunker, error) {
+	switch kind {
+	case FIXED_CHUNKING:
+		if avgSize < 1 || avgSize > MAX_BLOCK_SIZE {
+			return nil, fmt.Errorf("invalid block size: %v", avgSize)
+		}
+		return &FixedChunker{BlockSize: avgSize}, nil
+	case CDC_CHUNKING:
+		return NewFastCDCChunker(minSize, avgSize, maxSize)
+	}
+	return nil, fmt.Errorf("unknown chunking mode: %v", kind)
+}
+
+/* Fixed-size chunking */
+
+// FixedChunker splits files at multiples of BlockSize. Inserting a byte
+// shifts every later block, so all of them change.
+type FixedChunker struct {
+	BlockSize int
+}
+
+func (fc *FixedChunker) Chunks(r io.Reader, emit func(block []byte) error) error {
+	for {
+		block := make([]byte, fc.BlockSize)
+		n, err := io.ReadFull(r, block)
+		if n > 0 {
+			if err := emit(block[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+/* Content-defined chunking */
+
+// FastCDCChunker cuts blocks where a rolling gear hash of the last bytes
+// matches a mask, so boundaries move with the content and an insertion only
+// changes the blocks around it. Blocks are between MinSize and MaxSize
+// bytes, and normalized chunking keeps most of them close to AvgSize.
+//
+// See Xia et al., "FastCDC: a Fast and Efficient Content-Defined Chunking
+// Approach for Data Deduplication", USENIX ATC 2016.
+type FastCDCChunker struct {
+	MinSize int
+	AvgSize int
+	MaxSize int
+
+	// harder to match before AvgSize, easier after it
+	maskS uint64
+	maskL uint64
+}
+
+func NewFastCDCChunker(minSize, avgSize, maxSize int) (*FastCDCChunker, error) {
+	if minSize < 1 || avgSize < minSize || maxSize < avgSize || maxSize > MAX_BLOCK_SIZE {
+		return nil, fmt.Errorf("chunk sizes must satisfy 0 < min <= avg <= max <= %v, got %v/%v/%v", MAX_BLOCK_SIZE, minSize, avgSize, maxSize)
+	}
+	maskBits := bits.Len(uint(avgSize)) - 1
+	if maskBits < 2 {
+		maskBits = 2
+	}
+	return &FastCDCChunker{
+		MinSize: minSize,
+		AvgSize: avgSize,
+		MaxSize: maxSize,
+		maskS:   topBitsMask(maskBits + 1),
+		maskL:   topBitsMask(maskBits - 1),
+	}, nil
+}
+
+// A mask of the n most significant bits, which in a gear hash depend on
+// the most recent bytes
+func topBitsMask(n int) uint64 {
+	return ^uint64(0) << (64 - n)
+}
+
+func (cc *FastCDCChunker) Chunks(r io.Reader, emit func(block []byte) error) error {
+	buf := make([]byte, 0, cc.MaxSize)
+	eof := false
+	for {
+		if !eof && len(buf) < cc.MaxSize {
+			n, err := io.ReadFull(r, buf[len(buf):cc.MaxSize])
+			buf = buf[:len(buf)+n]
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if len(buf) == 0 {
+			return nil
+		}
+
+		cut := cc.cutPoint(buf)
+		block := make([]byte, cut)
+		copy(block, buf[:cut])
+		if err := emit(block); err != nil {
+			return err
+		}
+		buf = buf[:copy(buf, buf[cut:])]
+	}
+}
+
+// Length of the block starting at data[0]. data holds MaxSize bytes unless
+// the end of the file is near.
+func (cc *FastCDCChunker) cutPoint(data []byte) int {
+	n := len(data)
+	if n <= cc.MinSize {
+		return n
+	}
+	normal := cc.AvgSize
+	if n > cc.MaxSize {
+		n = cc.MaxSize
+	}
+	if n < normal {
+		normal = n
+	}
+
+	// the first MinSize bytes can never hold a boundary, so skip hashing them
+	var fp uint64
+	i := cc.MinSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gearTable[data[i]]
+		if fp&cc.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gearTable[data[i]]
+		if fp&cc.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// Random values the gear hash adds for each byte. They are generated from a
+// fixed seed since every client has to cut files at the same places.
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	state := uint64(0x5375726653746f72) // "SurfStor"
+	for i := range table {
+		// splitmix64
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
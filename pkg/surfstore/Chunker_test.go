@@ -0,0 +1,119 @@
+package surfstore
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// Split data into blocks
+func chunk(t *testing.T, chunker Chunker, data []byte) [][]byte {
+	blocks := [][]byte{}
+	err := chunker.Chunks(bytes.NewReader(data), func(block []byte) error {
+		blocks = append(blocks, block)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blocks
+}
+
+func randomData(seed int64, size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+func TestFastCDCBoundaries(t *testing.T) {
+	tests := []struct {
+		name                      string
+		minSize, avgSize, maxSize int
+		data                      []byte
+		// whether blocks should average about avgSize
+		average bool
+	}{
+		{"empty", 64, 256, 1024, []byte{}, false},
+		{"shorter than min", 64, 256, 1024, randomData(1, 10), false},
+		{"random", 64, 256, 1024, randomData(2, 64*1024), true},
+		{"large blocks", 1024, 4096, 16384, randomData(3, 256*1024), true},
+		// no boundary ever matches, so every block is cut at the maximum
+		{"zeros", 64, 256, 1024, make([]byte, 10000), false},
+		{"min equals max", 100, 100, 100, randomData(4, 1050), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chunker, err := NewFastCDCChunker(test.minSize, test.avgSize, test.maxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			blocks := chunk(t, chunker, test.data)
+			if !bytes.Equal(bytes.Join(blocks, nil), test.data) {
+				t.Fatal("blocks do not add up to the data")
+			}
+			for i, block := range blocks {
+				if len(block) == 0 || len(block) > test.maxSize {
+					t.Errorf("block %v has %v bytes", i, len(block))
+				}
+				if len(block) < test.minSize && i != len(blocks)-1 {
+					t.Errorf("block %v has %v bytes, less than the minimum", i, len(block))
+				}
+			}
+			if test.average {
+				average := len(test.data) / len(blocks)
+				if average < test.avgSize/2 || average > test.avgSize*2 {
+					t.Errorf("blocks average %v bytes, expected about %v", average, test.avgSize)
+				}
+			}
+		})
+	}
+}
+
+func TestFastCDCInsertion(t *testing.T) {
+	chunker, err := NewFastCDCChunker(64, 256, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := randomData(5, 64*1024)
+	edited := append(append(append([]byte{}, data[:1000]...), []byte("inserted")...), data[1000:]...)
+
+	original := map[string]bool{}
+	for _, block := range chunk(t, chunker, data) {
+		original[string(block)] = true
+	}
+	blocks := chunk(t, chunker, edited)
+	changed := 0
+	for _, block := range blocks {
+		if !original[string(block)] {
+			changed++
+		}
+	}
+	// only the blocks around the insertion change
+	if changed > 3 {
+		t.Errorf("%v of %v blocks changed after inserting bytes", changed, len(blocks))
+	}
+}
+
+func TestNewChunker(t *testing.T) {
+	tests := []struct {
+		kind                      string
+		minSize, avgSize, maxSize int
+		ok                        bool
+	}{
+		{FIXED_CHUNKING, 0, 4096, 0, true},
+		{FIXED_CHUNKING, 0, 0, 0, false},
+		{FIXED_CHUNKING, 0, MAX_BLOCK_SIZE + 1, 0, false},
+		{CDC_CHUNKING, 1024, 4096, 16384, true},
+		{CDC_CHUNKING, 0, 4096, 16384, false},
+		{CDC_CHUNKING, 1024, 512, 16384, false},
+		{CDC_CHUNKING, 1024, 4096, 2048, false},
+		{CDC_CHUNKING, 1024, 4096, MAX_BLOCK_SIZE + 1, false},
+		{"rabin", 1024, 4096, 16384, false},
+	}
+	for _, test := range tests {
+		_, err := NewChunker(test.kind, test.minSize, test.avgSize, test.maxSize)
+		if (err == nil) != test.ok {
+			t.Errorf("NewChunker(%q, %v, %v, %v) returned %v", test.kind, test.minSize, test.avgSize, test.maxSize, err)
+		}
+	}
+}
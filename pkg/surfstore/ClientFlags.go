@@ -0,0 +1,93 @@
+package surfstore
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	grpc "google.golang.org/grpc"
+)
+
+const TLS_NAME = "tls"
+const TLS_USAGE = "Connect over TLS, verifying servers against the system roots unless -ca is set"
+
+const CA_NAME = "ca"
+const CA_USAGE = "CA certificate servers are verified against (implies -tls)"
+
+const CERT_NAME = "cert"
+const CERT_USAGE = "Client certificate for servers requiring mutual TLS (implies -tls)"
+
+const KEY_NAME = "key"
+const KEY_USAGE = "Private key of the client certificate"
+
+const ENCRYPT_NAME = "encrypt"
+const ENCRYPT_USAGE = "Encrypt blocks and filenames with a key derived from the passphrase in $" + PASSPHRASE_ENV + ", or read from stdin"
+
+const CONVERGENT_NAME = "convergent"
+const CONVERGENT_USAGE = "With -encrypt, encrypt identical blocks identically so they are stored once"
+
+// ClientTLSFlags are the flags every client command takes to connect to
+// the servers over TLS
+type ClientTLSFlags struct {
+	UseTLS   *bool
+	CAFile   *string
+	CertFile *string
+	KeyFile  *string
+}
+
+// RegisterClientTLSFlags defines -tls, -ca, -cert and -key on flags
+func RegisterClientTLSFlags(flags *flag.FlagSet) *ClientTLSFlags {
+	return &ClientTLSFlags{
+		UseTLS:   flags.Bool(TLS_NAME, false, TLS_USAGE),
+		CAFile:   flags.String(CA_NAME, "", CA_USAGE),
+		CertFile: flags.String(CERT_NAME, "", CERT_USAGE),
+		KeyFile:  flags.String(KEY_NAME, "", KEY_USAGE),
+	}
+}
+
+// Write the usage lines of the flags to w, in the style of the commands'
+// usage messages
+func (f *ClientTLSFlags) PrintUsage(w io.Writer) {
+	fmt.Fprintf(w, "  -%s: %v\n", TLS_NAME, TLS_USAGE)
+	fmt.Fprintf(w, "  -%s: %v\n", CA_NAME, CA_USAGE)
+	fmt.Fprintf(w, "  -%s: %v\n", CERT_NAME, CERT_USAGE)
+	fmt.Fprintf(w, "  -%s: %v\n", KEY_NAME, KEY_USAGE)
+}
+
+// Option making connections use TLS as the flags ask, or nil for plaintext
+func (f *ClientTLSFlags) DialOption() (grpc.DialOption, error) {
+	if !*f.UseTLS && *f.CAFile == "" && *f.CertFile == "" {
+		return nil, nil
+	}
+	tlsConfig := &TLSConfig{CertFile: *f.CertFile, KeyFile: *f.KeyFile, CAFile: *f.CAFile}
+	return tlsConfig.DialOption()
+}
+
+// ClientCipherFlags are the flags client commands take to encrypt files end
+// to end
+type ClientCipherFlags struct {
+	Encrypt    *bool
+	Convergent *bool
+}
+
+// RegisterClientCipherFlags defines -encrypt and -convergent on flags
+func RegisterClientCipherFlags(flags *flag.FlagSet) *ClientCipherFlags {
+	return &ClientCipherFlags{
+		Encrypt:    flags.Bool(ENCRYPT_NAME, false, ENCRYPT_USAGE),
+		Convergent: flags.Bool(CONVERGENT_NAME, false, CONVERGENT_USAGE),
+	}
+}
+
+func (f *ClientCipherFlags) PrintUsage(w io.Writer) {
+	fmt.Fprintf(w, "  -%s: %v\n", ENCRYPT_NAME, ENCRYPT_USAGE)
+	fmt.Fprintf(w, "  -%s: %v\n", CONVERGENT_NAME, CONVERGENT_USAGE)
+}
+
+// Cipher the flags ask for, with the passphrase from ReadPassphrase, or nil
+// without -encrypt
+func (f *ClientCipherFlags) Cipher() (*Cipher, error) {
+	if !*f.Encrypt {
+		return nil, nil
+	}
+	return NewCipher(ReadPassphrase(), *f.Convergent)
+}
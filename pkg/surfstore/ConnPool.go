@@ -0,0 +1,89 @@
+package surfstore
+
+import (
+	"errors"
+	sync "sync"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Idle pooled connections are pinged this often, and dropped when a ping
+// goes unanswered for CONN_KEEPALIVE_TIMEOUT. Servers must allow pings at
+// least as often as CONN_KEEPALIVE_MIN_TIME.
+const CONN_KEEPALIVE_TIME = 30 * time.Second
+const CONN_KEEPALIVE_TIMEOUT = 10 * time.Second
+const CONN_KEEPALIVE_MIN_TIME = 20 * time.Second
+
+var errClientClosed = errors.New("client is closed")
+
+// A connPool keeps one connection open to every server a client talks to,
+// created on first use. gRPC reconnects a connection by itself when the
+// server goes away; a connection that keeps failing retries immediately on
+// its next use instead of waiting out gRPC's backoff. Connections are
+// shared by concurrent calls, so the pool never closes one in use.
+type connPool struct {
+	mtx    sync.Mutex
+	conns  map[string]*grpc.ClientConn
+	closed bool
+}
+
+func newConnPool() *connPool {
+	return &connPool{conns: map[string]*grpc.ClientConn{}}
+}
+
+// Connection to addr, dialed with opts if there is no usable one
+func (p *connPool) get(addr string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.closed {
+		return nil, errClientClosed
+	}
+	if conn, ok := p.conns[addr]; ok {
+		switch conn.GetState() {
+		case connectivity.Shutdown:
+			delete(p.conns, addr)
+		case connectivity.TransientFailure:
+			conn.ResetConnectBackoff()
+			return conn, nil
+		default:
+			return conn, nil
+		}
+	}
+	opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                CONN_KEEPALIVE_TIME,
+		Timeout:             CONN_KEEPALIVE_TIMEOUT,
+		PermitWithoutStream: true,
+	}))
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
+
+// Close every connection. The pool cannot be used afterwards.
+func (p *connPool) close() error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.closed = true
+	var firstErr error
+	for addr, conn := range p.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(p.conns, addr)
+	}
+	return firstErr
+}
+
+// Server option accepting the keepalive pings of pooled connections
+func KeepaliveServerOption() grpc.ServerOption {
+	return grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             CONN_KEEPALIVE_MIN_TIME,
+		PermitWithoutStream: true,
+	})
+}
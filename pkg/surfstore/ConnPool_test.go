@@ -0,0 +1,96 @@
+package surfstore
+
+import (
+	"errors"
+	"net"
+	sync "sync"
+	"sync/atomic"
+	"testing"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// A listener counting the connections it accepted
+type countingListener struct {
+	net.Listener
+	accepted int64
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt64(&l.accepted, 1)
+	}
+	return conn, err
+}
+
+func startCountingServer(t *testing.T) *countingListener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counting := &countingListener{Listener: l}
+	server := grpc.NewServer()
+	RegisterMetaStoreServer(server, NewMetaStore([]string{l.Addr().String()}))
+	RegisterBlockStoreServer(server, NewBlockStore())
+	go server.Serve(counting)
+	t.Cleanup(server.Stop)
+	return counting
+}
+
+func TestConnPoolReusesConnections(t *testing.T) {
+	servers := []*countingListener{startCountingServer(t), startCountingServer(t)}
+	client := NewSurfstoreRPCClient(servers[0].Addr().String(), t.TempDir(), 1024)
+	defer client.Close()
+
+	// concurrent calls of every kind, to both servers
+	var wg sync.WaitGroup
+	errs := make(chan error, 10*(3*len(servers)+1))
+	for i := 0; i < 10; i++ {
+		for _, server := range servers {
+			addr := server.Addr().String()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var succ bool
+				var hashes []string
+				block := &Block{BlockData: []byte("data"), BlockSize: 4}
+				errs <- client.PutBlock(block, addr, &succ)
+				errs <- client.HasBlocks([]string{GetBlockHashString(block.BlockData)}, addr, &hashes)
+				errs <- client.PutBlocks([]*Block{block}, addr, &succ)
+			}()
+		}
+		var fileInfoMap map[string]*FileMetaData
+		errs <- client.GetFileInfoMap(&fileInfoMap)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, server := range servers {
+		if accepted := atomic.LoadInt64(&server.accepted); accepted != 1 {
+			t.Errorf("server %v accepted %v connections, expected 1", i, accepted)
+		}
+	}
+
+	conns := []*grpc.ClientConn{}
+	for _, conn := range client.conns.conns {
+		conns = append(conns, conn)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, conn := range conns {
+		if state := conn.GetState(); state != connectivity.Shutdown {
+			t.Errorf("connection %v after Close", state)
+		}
+	}
+	var hashes []string
+	if err := client.HasBlocks([]string{"h"}, servers[0].Addr().String(), &hashes); !errors.Is(err, errClientClosed) {
+		t.Errorf("call after Close: %v", err)
+	}
+}
@@ -4,17 +4,25 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"log"
+	"math/big"
 	sort "sort"
+	"strconv"
 	"sync"
 )
 
+// Each server is placed on the ring VirtualNodes times its weight, so that
+// the hash space is split more evenly. The first virtual node of a server
+// hashes "blockstore"+addr, the same position a ring without virtual nodes
+// gives it.
 type ConsistentHashRing struct {
-	ServerMap map[string]string
-	HashList  []string
-	mtx       sync.Mutex
+	ServerMap    map[string]string
+	HashList     []string
+	VirtualNodes int
+	Weights      map[string]int
+	mtx          sync.Mutex
 }
 
-func (c ConsistentHashRing) GetResponsibleServer(blockId string) string {
+func (c *ConsistentHashRing) GetResponsibleServer(blockId string) string {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 	//hashedId := c.Hash(blockId)
@@ -22,24 +30,97 @@ func (c ConsistentHashRing) GetResponsibleServer(blockId string) string {
 	return c.ServerMap[HashedServer]
 }
 
-func (c ConsistentHashRing) Hash(addr string) string {
+// Return the n distinct servers found walking clockwise from blockId. The
+// first is the one GetResponsibleServer returns. If the ring has fewer than
+// n servers, all of them are returned.
+func (c *ConsistentHashRing) GetResponsibleServers(blockId string, n int) []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	servers := []string{}
+	if len(c.HashList) == 0 {
+		return servers
+	}
+	seen := map[string]bool{}
+	start := c.searchIndex(blockId)
+	for i := 0; i < len(c.HashList) && len(servers) < n; i++ {
+		server := c.ServerMap[c.HashList[(start+i)%len(c.HashList)]]
+		if !seen[server] {
+			seen[server] = true
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+func (c *ConsistentHashRing) Hash(addr string) string {
 	h := sha256.New()
 	h.Write([]byte(addr))
 	return hex.EncodeToString(h.Sum(nil))
 
 }
 
+// Fraction of the hash space each server is responsible for, i.e. the share
+// of blocks it is expected to be the first replica of. Useful to check how
+// balanced a ring is.
+func (c *ConsistentHashRing) Ownership() map[string]float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	ownership := map[string]float64{}
+	if len(c.HashList) == 0 {
+		return ownership
+	}
+
+	space := new(big.Int).Lsh(big.NewInt(1), sha256.Size*8)
+	owned := map[string]*big.Int{}
+	for i, hash := range c.HashList {
+		// a virtual node owns the arc from its predecessor (exclusive) to itself
+		prev := c.HashList[(i+len(c.HashList)-1)%len(c.HashList)]
+		arc := new(big.Int).Sub(hashToInt(hash), hashToInt(prev))
+		arc.Mod(arc, space)
+		if len(c.HashList) == 1 {
+			arc = space
+		}
+		server := c.ServerMap[hash]
+		if _, ok := owned[server]; !ok {
+			owned[server] = new(big.Int)
+		}
+		owned[server].Add(owned[server], arc)
+	}
+	for server, arc := range owned {
+		fraction, _ := new(big.Rat).SetFrac(arc, space).Float64()
+		ownership[server] = fraction
+	}
+	return ownership
+}
+
+func hashToInt(hash string) *big.Int {
+	n, _ := new(big.Int).SetString(hash, 16)
+	return n
+}
+
 func NewConsistentHashRing(serverAddrs []string) *ConsistentHashRing {
+	return NewWeightedConsistentHashRing(serverAddrs, map[string]int{}, 1)
+}
+
+// Create a ring placing each server virtualNodes times its weight. Servers
+// missing from weights have weight 1.
+func NewWeightedConsistentHashRing(serverAddrs []string, weights map[string]int, virtualNodes int) *ConsistentHashRing {
+	if weights == nil {
+		weights = map[string]int{}
+	}
 	c := ConsistentHashRing{
-		ServerMap: map[string]string{},
-		HashList:  []string{},
+		ServerMap:    map[string]string{},
+		HashList:     []string{},
+		VirtualNodes: virtualNodes,
+		Weights:      weights,
 	}
 	for _, addr := range serverAddrs {
-		hashedAddr := c.Hash("blockstore" + addr)
+		for _, hashedAddr := range c.virtualNodeHashes(addr) {
+			c.HashList = append(c.HashList, hashedAddr)
+			c.ServerMap[hashedAddr] = addr
+		}
 		log.Println("blockstore name: ", addr)
-		log.Println("blockstore hash value: ", hashedAddr)
-		c.HashList = append(c.HashList, hashedAddr)
-		c.ServerMap[hashedAddr] = addr
+		log.Println("blockstore hash value: ", c.Hash("blockstore"+addr))
 	}
 
 	sort.Strings(c.HashList)
@@ -47,35 +128,82 @@ func NewConsistentHashRing(serverAddrs []string) *ConsistentHashRing {
 	return &c
 }
 
-// Binary search over the HashList and return the hash of the server,
-// if next server not found, return the hash of the first server
-func (c ConsistentHashRing) Search(target string) string {
-	//length := len(c.HashList)
-	//var left, right int
-	//left, right = 0, length-1
-	//res := -1
-	//for left <= right {
-	//	mid := left + (right-left)/2
-	//	if c.HashList[mid] == target {
-	//		return c.HashList[mid]
-	//	} else if c.HashList[mid] < target {
-	//		left = mid + 1
-	//	} else {
-	//		res = mid
-	//		right = mid - 1
-	//	}
-	//}
-	//if res == -1 {
-	//	return c.HashList[0]
-	//} else {
-	//	return c.HashList[res]
-	//}
-	for _, addr := range c.HashList {
-		if target > addr {
-			continue
+// Place a server on the ring with the given weight. Adding a server that is
+// already on the ring only updates its weight.
+func (c *ConsistentHashRing) AddServer(addr string, weight int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.removeServerLocked(addr)
+	if weight < 1 {
+		weight = 1
+	}
+	c.Weights[addr] = weight
+	for _, hashedAddr := range c.virtualNodeHashes(addr) {
+		c.HashList = append(c.HashList, hashedAddr)
+		c.ServerMap[hashedAddr] = addr
+	}
+	sort.Strings(c.HashList)
+}
+
+// Weight of a server on the ring
+func (c *ConsistentHashRing) Weight(addr string) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if weight, ok := c.Weights[addr]; ok {
+		return weight
+	}
+	return 1
+}
+
+// Take a server and all its virtual nodes off the ring
+func (c *ConsistentHashRing) RemoveServer(addr string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.removeServerLocked(addr)
+	delete(c.Weights, addr)
+}
+
+func (c *ConsistentHashRing) removeServerLocked(addr string) {
+	hashList := []string{}
+	for _, hash := range c.HashList {
+		if c.ServerMap[hash] == addr {
+			delete(c.ServerMap, hash)
 		} else {
-			return addr
+			hashList = append(hashList, hash)
+		}
+	}
+	c.HashList = hashList
+}
+
+// Positions of all virtual nodes of a server on the ring
+func (c *ConsistentHashRing) virtualNodeHashes(addr string) []string {
+	weight, ok := c.Weights[addr]
+	if !ok {
+		weight = 1
+	}
+	hashes := []string{}
+	for i := 0; i < c.VirtualNodes*weight; i++ {
+		name := "blockstore" + addr
+		if i > 0 {
+			name += "#" + strconv.Itoa(i)
 		}
+		hashes = append(hashes, c.Hash(name))
+	}
+	return hashes
+}
+
+// Binary search over the HashList and return the hash of the server,
+// if next server not found, return the hash of the first server
+func (c *ConsistentHashRing) Search(target string) string {
+	return c.HashList[c.searchIndex(target)]
+}
+
+// Index in HashList of the first server hash at or after target, wrapping
+// around to 0 past the end of the ring
+func (c *ConsistentHashRing) searchIndex(target string) int {
+	i := sort.SearchStrings(c.HashList, target)
+	if i == len(c.HashList) {
+		return 0
 	}
-	return c.HashList[0]
+	return i
 }
@@ -0,0 +1,109 @@
+package surfstore
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestConsistentHashRingBalance(t *testing.T) {
+	servers := []string{"localhost:8081", "localhost:8082", "localhost:8083", "localhost:8084"}
+	tests := []struct {
+		name         string
+		weights      map[string]int
+		virtualNodes int
+		tolerance    float64
+	}{
+		{"single node", nil, 1, 1},
+		{"virtual nodes", nil, 200, 0.25},
+		{"weighted", map[string]int{"localhost:8081": 3}, 200, 0.25},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ring := NewWeightedConsistentHashRing(servers, test.weights, test.virtualNodes)
+			totalWeight := 0
+			for _, server := range servers {
+				totalWeight += ring.Weight(server)
+			}
+
+			ownership := ring.Ownership()
+			sum := 0.0
+			for _, server := range servers {
+				fraction := ownership[server]
+				sum += fraction
+				expected := float64(ring.Weight(server)) / float64(totalWeight)
+				if math.Abs(fraction-expected) > test.tolerance*expected {
+					t.Errorf("%v owns %.3f of the ring, expected %.3f", server, fraction, expected)
+				}
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("ownership sums to %v", sum)
+			}
+		})
+	}
+}
+
+func TestConsistentHashRingOwnership(t *testing.T) {
+	servers := []string{"localhost:8081", "localhost:8082", "localhost:8083"}
+	tests := []struct {
+		name     string
+		replicas int
+		expected int
+	}{
+		{"one replica", 1, 1},
+		{"majority", 2, 2},
+		{"every server", 3, 3},
+		{"more replicas than servers", 5, 3},
+	}
+	ring := NewWeightedConsistentHashRing(servers, nil, 10)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				blockHash := ring.Hash(fmt.Sprint("block", i))
+				owners := ring.GetResponsibleServers(blockHash, test.replicas)
+				if len(owners) != test.expected {
+					t.Fatalf("%v owners for %v replicas, expected %v", len(owners), test.replicas, test.expected)
+				}
+				if owners[0] != ring.GetResponsibleServer(blockHash) {
+					t.Errorf("first replica %v is not the responsible server %v", owners[0], ring.GetResponsibleServer(blockHash))
+				}
+				seen := map[string]bool{}
+				for _, owner := range owners {
+					if seen[owner] {
+						t.Errorf("%v holds two replicas of %v", owner, blockHash)
+					}
+					seen[owner] = true
+				}
+			}
+		})
+	}
+}
+
+func TestConsistentHashRingRemoveServer(t *testing.T) {
+	servers := []string{"localhost:8081", "localhost:8082", "localhost:8083"}
+	ring := NewWeightedConsistentHashRing(servers, nil, 10)
+	before := map[string]string{}
+	for i := 0; i < 200; i++ {
+		blockHash := ring.Hash(fmt.Sprint("block", i))
+		before[blockHash] = ring.GetResponsibleServer(blockHash)
+	}
+
+	ring.RemoveServer("localhost:8082")
+	for blockHash, owner := range before {
+		now := ring.GetResponsibleServer(blockHash)
+		if now == "localhost:8082" {
+			t.Fatalf("%v still owned by the removed server", blockHash)
+		}
+		// only the removed server's blocks move
+		if owner != "localhost:8082" && now != owner {
+			t.Errorf("%v moved from %v to %v", blockHash, owner, now)
+		}
+	}
+
+	ring.AddServer("localhost:8082", 1)
+	for blockHash, owner := range before {
+		if now := ring.GetResponsibleServer(blockHash); now != owner {
+			t.Errorf("%v owned by %v after re-adding, was %v", blockHash, now, owner)
+		}
+	}
+}
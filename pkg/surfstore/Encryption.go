@@ -0,0 +1,162 @@
+package surfstore
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PBKDF2 parameters of the passphrase key. The salt is fixed because every
+// client of a user has to derive the same key from the passphrase alone.
+const KDF_ITERATIONS int = 200000
+const KDF_SALT string = "surfstore-e2e-v1"
+
+// Environment variable the client reads the passphrase from
+const PASSPHRASE_ENV string = "SURFSTORE_PASSPHRASE"
+
+var errDecrypt = errors.New("decryption failed, wrong passphrase or corrupted data")
+
+// A Cipher encrypts blocks and filenames on the client, so block servers
+// and the MetaStore only ever see ciphertext. Encryption is deterministic,
+// with a synthetic nonce computed from the plaintext: syncing an unchanged
+// file produces the same block hashes, and the server can keep comparing
+// hash lists.
+//
+// By default a block's nonce also depends on the name of its file, so the
+// same content in two files encrypts differently. With Convergent set,
+// identical blocks encrypt identically wherever they appear, so blocks
+// shared between files are stored once; anyone watching the block servers
+// can then tell which blocks are equal. Decryption works the same either
+// way, so clients with different settings can read each other's files.
+type Cipher struct {
+	Convergent bool
+
+	blockAEAD cipher.AEAD
+	blockMAC  []byte
+	nameAEAD  cipher.AEAD
+	nameMAC   []byte
+}
+
+// ReadPassphrase returns the passphrase in $SURFSTORE_PASSPHRASE, or else
+// asks for it on stdin
+func ReadPassphrase() string {
+	if passphrase := os.Getenv(PASSPHRASE_ENV); passphrase != "" {
+		return passphrase
+	}
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+func NewCipher(passphrase string, convergent bool) (*Cipher, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	master := pbkdf2.Key([]byte(passphrase), []byte(KDF_SALT), KDF_ITERATIONS, sha256.Size, sha256.New)
+	blockAEAD, err := newAEAD(deriveKey(master, "block"))
+	if err != nil {
+		return nil, err
+	}
+	nameAEAD, err := newAEAD(deriveKey(master, "filename"))
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{
+		Convergent: convergent,
+		blockAEAD:  blockAEAD,
+		blockMAC:   deriveKey(master, "block nonce"),
+		nameAEAD:   nameAEAD,
+		nameMAC:    deriveKey(master, "filename nonce"),
+	}, nil
+}
+
+// Encrypt a block of filename. The result is the nonce followed by the
+// sealed data.
+func (c *Cipher) EncryptBlock(filename string, data []byte) []byte {
+	nonceContext := ""
+	if !c.Convergent {
+		nonceContext = filename
+	}
+	return sealDeterministic(c.blockAEAD, c.blockMAC, nonceContext, data)
+}
+
+func (c *Cipher) DecryptBlock(data []byte) ([]byte, error) {
+	return openSealed(c.blockAEAD, data)
+}
+
+// Encrypt every component of a slash separated filename, so the server
+// still sees the directory tree and a directory's name is a prefix of the
+// names below it. Each component is encrypted in the context of its
+// plaintext parent, so equal names in different directories differ.
+func (c *Cipher) EncryptFilename(filename string) string {
+	parts := strings.Split(filename, "/")
+	encrypted := make([]string, len(parts))
+	for i, part := range parts {
+		sealed := sealDeterministic(c.nameAEAD, c.nameMAC, strings.Join(parts[:i], "/"), []byte(part))
+		encrypted[i] = base64.RawURLEncoding.EncodeToString(sealed)
+	}
+	return strings.Join(encrypted, "/")
+}
+
+func (c *Cipher) DecryptFilename(filename string) (string, error) {
+	parts := strings.Split(filename, "/")
+	for i, part := range parts {
+		sealed, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return "", errDecrypt
+		}
+		plain, err := openSealed(c.nameAEAD, sealed)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = string(plain)
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// Seal plaintext with a nonce derived from it and context, so the same
+// input always gives the same output
+func sealDeterministic(aead cipher.AEAD, macKey []byte, context string, plaintext []byte) []byte {
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write([]byte(context))
+	mac.Write([]byte{0})
+	mac.Write(plaintext)
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+	return aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+func openSealed(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errDecrypt
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errDecrypt
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Independent key for one purpose, derived from the passphrase key
+func deriveKey(master []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
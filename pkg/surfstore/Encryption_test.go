@@ -0,0 +1,106 @@
+package surfstore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCipherDeterministic(t *testing.T) {
+	block := []byte("the same block in two files")
+	tests := []struct {
+		name       string
+		convergent bool
+		file1      string
+		file2      string
+		equal      bool
+	}{
+		{"same file", false, "a.txt", "a.txt", true},
+		{"different files", false, "a.txt", "b.txt", false},
+		{"convergent same file", true, "a.txt", "a.txt", true},
+		{"convergent different files", true, "a.txt", "b.txt", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cipher, err := NewCipher("passphrase", test.convergent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sealed1 := cipher.EncryptBlock(test.file1, block)
+			sealed2 := cipher.EncryptBlock(test.file2, block)
+			if bytes.Equal(sealed1, sealed2) != test.equal {
+				t.Errorf("ciphertexts equal: %v, expected %v", bytes.Equal(sealed1, sealed2), test.equal)
+			}
+			if bytes.Contains(sealed1, block) {
+				t.Error("ciphertext contains the plaintext")
+			}
+			for _, sealed := range [][]byte{sealed1, sealed2} {
+				plain, err := cipher.DecryptBlock(sealed)
+				if err != nil || !bytes.Equal(plain, block) {
+					t.Errorf("decrypted %q, %v", plain, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCipherFilenames(t *testing.T) {
+	cipher, err := NewCipher("passphrase", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		filename string
+		parent   string
+	}{
+		{"a.txt", ""},
+		{"dir/a.txt", "dir"},
+		{"dir/sub/a.txt", "dir/sub"},
+		{"other/a.txt", "other"},
+	}
+	seen := map[string]string{}
+	for _, test := range tests {
+		encrypted := cipher.EncryptFilename(test.filename)
+		if encrypted != cipher.EncryptFilename(test.filename) {
+			t.Errorf("%v encrypts differently twice", test.filename)
+		}
+		if strings.Contains(encrypted, "a.txt") {
+			t.Errorf("%v leaks into %v", test.filename, encrypted)
+		}
+		// a directory's encrypted name is a prefix of the names below it
+		if test.parent != "" && !strings.HasPrefix(encrypted, cipher.EncryptFilename(test.parent)+"/") {
+			t.Errorf("%v is not below the encrypted %v", encrypted, test.parent)
+		}
+		// equal names in different directories differ
+		base := encrypted[strings.LastIndex(encrypted, "/")+1:]
+		if other, ok := seen[base]; ok {
+			t.Errorf("%v and %v encrypt their names the same", test.filename, other)
+		}
+		seen[base] = test.filename
+
+		decrypted, err := cipher.DecryptFilename(encrypted)
+		if err != nil || decrypted != test.filename {
+			t.Errorf("decrypted %v to %q, %v", test.filename, decrypted, err)
+		}
+	}
+}
+
+func TestCipherWrongPassphrase(t *testing.T) {
+	cipher, err := NewCipher("passphrase", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewCipher("another passphrase", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.DecryptBlock(cipher.EncryptBlock("a.txt", []byte("data"))); err == nil {
+		t.Error("decrypted a block with the wrong passphrase")
+	}
+	if _, err := other.DecryptFilename(cipher.EncryptFilename("dir/a.txt")); err == nil {
+		t.Error("decrypted a filename with the wrong passphrase")
+	}
+	if _, err := NewCipher("", false); err == nil {
+		t.Error("accepted an empty passphrase")
+	}
+}
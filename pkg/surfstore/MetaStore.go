@@ -1,10 +1,18 @@
 package surfstore
 
 import (
+	"container/heap"
 	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	log "log"
+	"reflect"
+	"strings"
 	sync "sync"
+	"time"
 )
 
 type MetaStore struct {
@@ -12,37 +20,426 @@ type MetaStore struct {
 	mtx                sync.Mutex
 	BlockStoreAddrs    []string
 	ConsistentHashRing *ConsistentHashRing
+	ReplicationFactor  int
+	wal                *WriteAheadLog
+
+	// folders users shared with each other, by shareKey
+	shares map[string]*Share
+
+	// updates are numbered as they are applied; changeLog holds the last
+	// CHANGE_LOG_LIMIT of them in order, those numbered up to
+	// changeLogStart having been dropped, changeSequences the number of
+	// the last update of each file, shareSequences that of the last change
+	// to the folders shared with each user, and changed is closed and
+	// replaced after every update to wake the change streams. The epoch
+	// names the numbering, which starts over when the state is lost.
+	epoch           string
+	changeSequence  int64
+	changeLog       []*FileChange
+	changeLogStart  int64
+	changeSequences map[string]int64
+	shareSequences  map[string]int64
+	changed         chan struct{}
+
+	// earlier versions of each file, oldest first, at most HistoryLimit of
+	// them
+	history      map[string][]*FileMetaData
+	HistoryLimit int
+
+	// the last version of each deleted file, by key, kept for
+	// TrashRetention after its deletion, and the same files ordered by the
+	// time they expire
+	trash          map[string]*TrashedFile
+	trashQueue     trashQueue
+	TrashRetention time.Duration
+
+	// blocks written or confirmed this recently are never deleted, neither
+	// by the garbage collector nor by the rebalancer
+	GCGracePeriod time.Duration
+
+	// used to connect to block servers and Raft peers, plaintext if nil,
+	// and the token presented to them
+	DialOption grpc.DialOption
+	Token      string
+
+	// servers removed since the last complete rebalance, whose blocks may
+	// still have to be copied to their new owners
+	retiredAddrs map[string]bool
+	rebalanceCh  chan struct{}
+
+	// rebalancing and garbage collection only run where isLeader holds, and
+	// never at the same time
+	isLeader       func() bool
+	maintenanceMtx sync.Mutex
 	UnimplementedMetaStoreServer
 }
 
+// Returns the files in the caller's namespace, keyed by their plain names,
+// and the files shared with the caller
 func (m *MetaStore) GetFileInfoMap(ctx context.Context, _ *emptypb.Empty) (*FileInfoMap, error) {
+	user := UserFromContext(ctx)
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
-	return &FileInfoMap{FileInfoMap: m.FileMetaMap}, nil
+	fileInfoMap := map[string]*FileMetaData{}
+	for key, fileMetaData := range m.FileMetaMap {
+		if owner, filename := splitNamespacedName(key); owner == user {
+			fileInfoMap[filename] = &FileMetaData{
+				Filename:      filename,
+				Version:       fileMetaData.Version,
+				BlockHashList: fileMetaData.BlockHashList,
+			}
+		}
+	}
+	if user != "" {
+		m.addSharedFiles(user, fileInfoMap)
+	}
+	return &FileInfoMap{FileInfoMap: fileInfoMap}, nil
 }
 
 func (m *MetaStore) UpdateFile(ctx context.Context, fileMetaData *FileMetaData) (*Version, error) {
-	filename := fileMetaData.Filename
+	entry, err := m.updateEntry(ctx, fileMetaData)
+	if err != nil {
+		return nil, err
+	}
+	return m.updateFile(entry)
+}
+
+// Apply an update whose filename is already a namespaced key. An update a
+// user makes to a folder shared with it is rejected if the share was
+// revoked, or made read-only, since it was proposed.
+func (m *MetaStore) updateFile(entry *LogEntry) (*Version, error) {
+	fileMetaData := entry.FileMetaData
 	version := fileMetaData.Version
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
-	log.Println("fileName: ", filename)
-
-	if _, ok := m.FileMetaMap[filename]; ok {
-		currentVersion := m.FileMetaMap[filename].Version
-		if version == currentVersion+1 {
-			m.FileMetaMap[filename] = fileMetaData
-		} else {
-			return &Version{Version: -1}, nil
+
+	if owner, path := splitNamespacedName(fileMetaData.Filename); entry.User != "" && owner != entry.User &&
+		m.permission(owner, path, entry.User) != Permission_READ_WRITE {
+		return nil, status.Errorf(codes.PermissionDenied, "no write access to %v", SHARED_DIR+"/"+owner+"/"+path)
+	}
+	if !m.canUpdate(fileMetaData) {
+		if m.alreadyApplied(fileMetaData) {
+			return &Version{Version: version}, nil
 		}
-	} else {
-		m.FileMetaMap[fileMetaData.Filename] = fileMetaData
+		return &Version{Version: -1}, nil
 	}
+	if err := m.logEntry(entry); err != nil {
+		log.Println("Failed to log update: ", err)
+		return nil, err
+	}
+	m.applyUpdate(fileMetaData)
+	m.maybeSnapshot()
 
 	return &Version{Version: version}, nil
 }
 
+// Make an accepted update the current version of its file. Must hold m.mtx.
+func (m *MetaStore) applyUpdate(fileMetaData *FileMetaData) {
+	key := fileMetaData.Filename
+	if current, ok := m.FileMetaMap[key]; ok {
+		m.keepVersion(key, current)
+		m.trashVersion(key, current, fileMetaData)
+	}
+	m.FileMetaMap[key] = fileMetaData
+	m.recordChange(fileMetaData)
+	m.purgeTrash(fileMetaData.CommittedAt)
+}
+
+// Add a block server to the ring, or change its weight if it is already a
+// member, and start moving the blocks it is now responsible for onto it.
+func (m *MetaStore) AddBlockStore(ctx context.Context, blockStoreAddr *BlockStoreAddr) (*Success, error) {
+	succ, err := m.addBlockStore(blockStoreAddr)
+	if err == nil && succ.Flag {
+		m.requestRebalance()
+	}
+	return succ, err
+}
+
+// Remove a block server from the ring and start moving its blocks to the
+// servers now responsible for them. The server must stay up until the
+// rebalance has finished.
+func (m *MetaStore) RemoveBlockStore(ctx context.Context, blockStoreAddr *BlockStoreAddr) (*Success, error) {
+	succ, err := m.removeBlockStore(blockStoreAddr)
+	if err == nil && succ.Flag {
+		m.requestRebalance()
+	}
+	return succ, err
+}
+
+func (m *MetaStore) addBlockStore(blockStoreAddr *BlockStoreAddr) (*Success, error) {
+	addr := blockStoreAddr.Addr
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	log.Println("adding blockstore: ", addr)
+
+	if addr == "" || blockStoreAddr.Weight < 0 {
+		return &Success{Flag: false}, nil
+	}
+	if err := m.logEntry(&LogEntry{AddBlockStore: blockStoreAddr}); err != nil {
+		log.Println("Failed to log update: ", err)
+		return nil, err
+	}
+	if !m.isBlockStore(addr) {
+		m.BlockStoreAddrs = append(m.BlockStoreAddrs, addr)
+	}
+	delete(m.retiredAddrs, addr)
+	m.ConsistentHashRing.AddServer(addr, int(blockStoreAddr.Weight))
+	m.maybeSnapshot()
+
+	return &Success{Flag: true}, nil
+}
+
+func (m *MetaStore) removeBlockStore(blockStoreAddr *BlockStoreAddr) (*Success, error) {
+	addr := blockStoreAddr.Addr
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	log.Println("removing blockstore: ", addr)
+
+	// the last server cannot go, there would be nowhere to move its blocks
+	if !m.isBlockStore(addr) || len(m.BlockStoreAddrs) == 1 {
+		return &Success{Flag: false}, nil
+	}
+	if err := m.logEntry(&LogEntry{RemoveBlockStore: blockStoreAddr}); err != nil {
+		log.Println("Failed to log update: ", err)
+		return nil, err
+	}
+	blockStoreAddrs := []string{}
+	for _, member := range m.BlockStoreAddrs {
+		if member != addr {
+			blockStoreAddrs = append(blockStoreAddrs, member)
+		}
+	}
+	m.BlockStoreAddrs = blockStoreAddrs
+	m.retiredAddrs[addr] = true
+	m.ConsistentHashRing.RemoveServer(addr)
+	m.maybeSnapshot()
+
+	return &Success{Flag: true}, nil
+}
+
+func (m *MetaStore) isBlockStore(addr string) bool {
+	for _, member := range m.BlockStoreAddrs {
+		if member == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// An update is accepted for a new file, or if it is exactly one version
+// ahead of what the server has.
+func (m *MetaStore) canUpdate(fileMetaData *FileMetaData) bool {
+	if current, ok := m.FileMetaMap[fileMetaData.Filename]; ok {
+		return fileMetaData.Version == current.Version+1
+	}
+	return true
+}
+
+// Whether the version an update creates already exists with the same
+// content, as the current version or one kept in the history. A client
+// resends an update whose answer it lost, such as a Raft proposal that timed
+// out but still committed, and must not have it rejected as a conflict.
+// Must hold m.mtx.
+func (m *MetaStore) alreadyApplied(fileMetaData *FileMetaData) bool {
+	for _, kept := range m.versions(fileMetaData.Filename) {
+		if kept.Version == fileMetaData.Version && reflect.DeepEqual(kept.BlockHashList, fileMetaData.BlockHashList) {
+			return true
+		}
+	}
+	return false
+}
+
+// Log entry of an update from a client, with the filename moved into the
+// client's namespace, or into the owner's namespace for a file in a folder
+// shared with the client, which needs read-write access. Such an entry
+// names the client, so the access is checked again when it is applied. The
+// update is stamped with the time it arrived, which is logged along with
+// it.
+func (m *MetaStore) updateEntry(ctx context.Context, fileMetaData *FileMetaData) (*LogEntry, error) {
+	filename := fileMetaData.Filename
+	if strings.Contains(filename, NAMESPACE_SEPARATOR) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filename: %q", filename)
+	}
+	user := UserFromContext(ctx)
+	key := namespacedName(user, filename)
+	writer := ""
+	if user != "" && isSharedName(filename) {
+		owner, path, ok := splitSharedName(filename)
+		m.mtx.Lock()
+		permission := Permission_NO_ACCESS
+		if ok {
+			permission = m.permission(owner, path, user)
+		}
+		m.mtx.Unlock()
+		if permission != Permission_READ_WRITE {
+			return nil, status.Errorf(codes.PermissionDenied, "no write access to %v", filename)
+		}
+		key = namespacedName(owner, path)
+		writer = user
+	}
+	return &LogEntry{
+		FileMetaData: &FileMetaData{
+			Filename:      key,
+			Version:       fileMetaData.Version,
+			BlockHashList: fileMetaData.BlockHashList,
+			CommittedAt:   time.Now().UnixMilli(),
+		},
+		User: writer,
+	}, nil
+}
+
+// Durably log an entry before it is applied. Must hold m.mtx.
+func (m *MetaStore) logEntry(entry *LogEntry) error {
+	if m.wal == nil {
+		return nil
+	}
+	return m.wal.Append(entry)
+}
+
+// Snapshot the state once enough entries have been logged. Must hold m.mtx
+// and be called after the logged entries were applied.
+func (m *MetaStore) maybeSnapshot() {
+	if m.wal == nil || !m.wal.ShouldSnapshot() {
+		return
+	}
+	// the entries are already durable, so a failed snapshot only means a
+	// longer replay on the next start
+	if err := m.wal.Snapshot(m.snapshot()); err != nil {
+		log.Println("Failed to snapshot metastore: ", err)
+	}
+}
+
+// Apply a logged entry to the MetaStore. This is used both to replay the
+// write-ahead log and to apply entries committed by Raft. Entries that carry
+// no operation, such as the one a new Raft leader appends, return nil.
+func (m *MetaStore) applyEntry(ctx context.Context, entry *LogEntry) (proto.Message, error) {
+	switch {
+	case entry.FileMetaData != nil:
+		return m.updateFile(entry)
+	case entry.AddBlockStore != nil:
+		return m.addBlockStore(entry.AddBlockStore)
+	case entry.RemoveBlockStore != nil:
+		return m.removeBlockStore(entry.RemoveBlockStore)
+	case entry.Share != nil:
+		return m.share(entry.Share)
+	case entry.Unshare != nil:
+		return m.unshare(entry.Unshare)
+	case entry.Undelete != nil:
+		return m.undelete(entry.Undelete)
+	case entry.Epoch != "":
+		return m.setEpoch(entry.Epoch)
+	}
+	return nil, nil
+}
+
+func (m *MetaStore) snapshot() *MetaStoreSnapshot {
+	blockStores := []*BlockStoreAddr{}
+	for _, addr := range m.BlockStoreAddrs {
+		weight := m.ConsistentHashRing.Weight(addr)
+		blockStores = append(blockStores, &BlockStoreAddr{Addr: addr, Weight: int32(weight)})
+	}
+	shares := []*Share{}
+	for _, share := range m.shares {
+		shares = append(shares, share)
+	}
+	history := map[string]*FileVersions{}
+	for key, versions := range m.history {
+		history[key] = &FileVersions{Versions: versions}
+	}
+	trash := []*TrashedFile{}
+	for _, trashed := range m.trash {
+		trash = append(trash, trashed)
+	}
+	return &MetaStoreSnapshot{
+		FileMetaMap:     m.FileMetaMap,
+		BlockStores:     blockStores,
+		Shares:          shares,
+		Epoch:           m.epoch,
+		ChangeSequence:  m.changeSequence,
+		ChangeLog:       m.changeLog,
+		ChangeLogStart:  m.changeLogStart,
+		ChangeSequences: m.changeSequences,
+		ShareSequences:  m.shareSequences,
+		History:         history,
+		Trash:           trash,
+	}
+}
+
+func (m *MetaStore) restore(snapshot *MetaStoreSnapshot) {
+	if snapshot.FileMetaMap != nil {
+		m.FileMetaMap = snapshot.FileMetaMap
+	}
+	m.shares = map[string]*Share{}
+	for _, share := range snapshot.Shares {
+		m.shares[shareKey(share)] = share
+	}
+	if snapshot.Epoch != "" {
+		m.epoch = snapshot.Epoch
+	}
+	m.changeSequence = snapshot.ChangeSequence
+	m.changeLog = snapshot.ChangeLog
+	m.changeLogStart = snapshot.ChangeLogStart
+	if len(m.changeLog) == 0 {
+		// nothing is kept of the changes so far, also in snapshots from
+		// before the log was kept
+		m.changeLogStart = m.changeSequence
+	}
+	m.changeSequences = map[string]int64{}
+	for key, sequence := range snapshot.ChangeSequences {
+		m.changeSequences[key] = sequence
+	}
+	m.shareSequences = map[string]int64{}
+	for user, sequence := range snapshot.ShareSequences {
+		m.shareSequences[user] = sequence
+	}
+	m.history = map[string][]*FileMetaData{}
+	for key, versions := range snapshot.History {
+		// the limit may have been lowered since
+		for _, fileMetaData := range versions.Versions {
+			m.keepVersion(key, fileMetaData)
+		}
+	}
+	m.trash = map[string]*TrashedFile{}
+	m.trashQueue = trashQueue{}
+	if m.TrashRetention > 0 {
+		for _, trashed := range snapshot.Trash {
+			m.trash[trashed.FileMetaData.Filename] = trashed
+			m.trashQueue = append(m.trashQueue, trashed)
+		}
+		heap.Init(&m.trashQueue)
+	}
+	if len(snapshot.BlockStores) > 0 {
+		blockStoreAddrs := []string{}
+		weights := map[string]int{}
+		for _, blockStore := range snapshot.BlockStores {
+			blockStoreAddrs = append(blockStoreAddrs, blockStore.Addr)
+			weights[blockStore.Addr] = int(blockStore.Weight)
+		}
+		m.BlockStoreAddrs = blockStoreAddrs
+		m.ConsistentHashRing = NewWeightedConsistentHashRing(blockStoreAddrs, weights, m.ConsistentHashRing.VirtualNodes)
+	}
+}
+
+// A copy of the whole state, for Raft to compact its log into
+func (m *MetaStore) exportSnapshot() *MetaStoreSnapshot {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return proto.Clone(m.snapshot()).(*MetaStoreSnapshot)
+}
+
+// Replace the whole state with a snapshot a Raft leader sent, and wake the
+// change streams
+func (m *MetaStore) installSnapshot(snapshot *MetaStoreSnapshot) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.FileMetaMap = map[string]*FileMetaData{}
+	m.restore(proto.Clone(snapshot).(*MetaStoreSnapshot))
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
 // Given a list of block hashes, find out which block server they belong to. Returns a mapping from block server address to block hashes.
+// Each block is stored on ReplicationFactor servers, so a hash appears under every server holding a replica of it.
 func (m *MetaStore) GetBlockStoreMap(ctx context.Context, blockHashesIn *BlockHashes) (*BlockStoreMap, error) {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
@@ -50,11 +447,12 @@ func (m *MetaStore) GetBlockStoreMap(ctx context.Context, blockHashesIn *BlockHa
 	//log.Println("entering GetBlockStoreMap")
 
 	for _, hash := range blockHashesIn.Hashes {
-		blockAddr := m.ConsistentHashRing.GetResponsibleServer(hash)
-		if _, ok := blockHashes[blockAddr]; ok {
-			blockHashes[blockAddr].Hashes = append(blockHashes[blockAddr].Hashes, hash)
-		} else {
-			blockHashes[blockAddr] = &BlockHashes{Hashes: []string{hash}}
+		for _, blockAddr := range m.ConsistentHashRing.GetResponsibleServers(hash, m.ReplicationFactor) {
+			if _, ok := blockHashes[blockAddr]; ok {
+				blockHashes[blockAddr].Hashes = append(blockHashes[blockAddr].Hashes, hash)
+			} else {
+				blockHashes[blockAddr] = &BlockHashes{Hashes: []string{hash}}
+			}
 		}
 	}
 	//log.Println("Finishing GetBlockStore")
@@ -63,6 +461,8 @@ func (m *MetaStore) GetBlockStoreMap(ctx context.Context, blockHashesIn *BlockHa
 
 // Returns all the BlockStore addresses.
 func (m *MetaStore) GetBlockStoreAddrs(ctx context.Context, _ *emptypb.Empty) (*BlockStoreAddrs, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
 	return &BlockStoreAddrs{BlockStoreAddrs: m.BlockStoreAddrs}, nil
 }
 
@@ -70,9 +470,58 @@ func (m *MetaStore) GetBlockStoreAddrs(ctx context.Context, _ *emptypb.Empty) (*
 var _ MetaStoreInterface = new(MetaStore)
 
 func NewMetaStore(blockStoreAddrs []string) *MetaStore {
-	return &MetaStore{
+	m := &MetaStore{
 		FileMetaMap:        map[string]*FileMetaData{},
 		BlockStoreAddrs:    blockStoreAddrs,
 		ConsistentHashRing: NewConsistentHashRing(blockStoreAddrs),
+		ReplicationFactor:  1,
+		shares:             map[string]*Share{},
+		epoch:              newEpoch(),
+		changeSequences:    map[string]int64{},
+		shareSequences:     map[string]int64{},
+		changed:            make(chan struct{}),
+		history:            map[string][]*FileMetaData{},
+		HistoryLimit:       DEFAULT_HISTORY_LIMIT,
+		trash:              map[string]*TrashedFile{},
+		TrashRetention:     DEFAULT_TRASH_RETENTION,
+		GCGracePeriod:      DEFAULT_GC_GRACE_PERIOD,
+		retiredAddrs:       map[string]bool{},
+		rebalanceCh:        make(chan struct{}, 1),
+		isLeader:           func() bool { return true },
+	}
+	go m.rebalanceLoop()
+	return m
+}
+
+// Recover replays the state persisted under dataDir by a previous run and
+// keeps persisting updates there. The ring must be configured beforehand,
+// since logged membership changes are applied on top of it.
+func (m *MetaStore) Recover(dataDir string) error {
+	wal, snapshot, entries, err := OpenWriteAheadLog(dataDir)
+	if err != nil {
+		return err
+	}
+	if snapshot != nil {
+		m.restore(snapshot)
+	}
+	for _, entry := range entries {
+		if _, err := m.applyEntry(context.Background(), entry); err != nil {
+			return err
+		}
+	}
+	log.Printf("metastore recovered %v files from %v\n", len(m.FileMetaMap), dataDir)
+	m.wal = wal
+	if snapshot == nil || snapshot.Epoch == "" {
+		// the epoch has to survive restarts, or every client would list
+		// all its files again after each
+		m.mtx.Lock()
+		err := wal.Snapshot(m.snapshot())
+		m.mtx.Unlock()
+		if err != nil {
+			return err
+		}
 	}
+	// finish any migration interrupted by the restart
+	m.requestRebalance()
+	return nil
 }
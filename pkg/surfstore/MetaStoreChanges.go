@@ -0,0 +1,213 @@
+package surfstore
+
+import (
+	context "context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// How often a change stream checks that its server is still the leader,
+// while no changes arrive
+const WATCH_LEADER_CHECK_INTERVAL = time.Second
+
+// Number of changes GetChangesSince returns at most, unless asked for
+// fewer
+const MAX_CHANGES_PAGE_SIZE int = 1000
+
+// Number of updates kept in the change log for change streams to resume
+// from
+const CHANGE_LOG_LIMIT int = 10000
+
+// Returns the caller's files, and the files shared with it, that changed
+// after the cursor, in the order they last changed, at most request.Limit
+// of them. The cursor returned is where the next call continues; HasMore
+// says whether there are more changes right away. Resync means the caller
+// has to list all its files with GetFileInfoMap instead, and then continue
+// from the cursor returned: it has no cursor yet, the cursor is from before
+// the folders shared with it changed or older than the change log, or it
+// is from another epoch, numbered by a server that lost its state since.
+func (m *MetaStore) GetChangesSince(ctx context.Context, request *ChangesRequest) (*Changes, error) {
+	user := UserFromContext(ctx)
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	cursor := request.Cursor
+	if request.Epoch != m.epoch || cursor < m.changeLogStart || cursor > m.changeSequence ||
+		m.shareSequences[user] > cursor {
+		return &Changes{Cursor: m.changeSequence, Epoch: m.epoch, Resync: true}, nil
+	}
+
+	limit := int(request.Limit)
+	if limit <= 0 || limit > MAX_CHANGES_PAGE_SIZE {
+		limit = MAX_CHANGES_PAGE_SIZE
+	}
+	page := &Changes{Cursor: m.changeSequence, Epoch: m.epoch}
+	start := sort.Search(len(m.changeLog), func(i int) bool {
+		return m.changeLog[i].Sequence > cursor
+	})
+	for i := start; i < len(m.changeLog); i++ {
+		change := m.changeLog[i]
+		key := change.FileMetaData.Filename
+		// a file changed again later is sent with its later change
+		if m.changeSequences[key] != change.Sequence {
+			continue
+		}
+		filename, ok := m.visibleName(user, key)
+		if !ok {
+			continue
+		}
+		page.Changes = append(page.Changes, &FileMetaData{
+			Filename:      filename,
+			Version:       change.FileMetaData.Version,
+			BlockHashList: change.FileMetaData.BlockHashList,
+		})
+		if len(page.Changes) == limit && i+1 < len(m.changeLog) {
+			page.Cursor = change.Sequence
+			page.HasMore = true
+			break
+		}
+	}
+	return page, nil
+}
+
+// Stream every update to the caller's files, and to the files shared with
+// it, committed after sequence number since, in order, and then every
+// update as it is committed, until the caller goes away. After the updates
+// made before the call, an event without a file carries the sequence number
+// the stream caught up to, so the caller can resume from there. It has
+// Resync set if the updates after since are no longer kept, or since is
+// ahead of the server, as after a restart without -datadir; the caller has
+// to list all its files with GetFileInfoMap then, and the stream goes on
+// from the sequence number it carries. Changes to shares are not reported.
+func (m *MetaStore) WatchChanges(request *WatchRequest, stream MetaStore_WatchChangesServer) error {
+	ctx := stream.Context()
+	user := UserFromContext(ctx)
+	since := request.Since
+
+	m.mtx.Lock()
+	changes, ok := m.changeLogSince(user, since)
+	if request.Epoch != m.epoch {
+		changes, ok = nil, false
+	}
+	changed := m.changed
+	caughtUp := &FileChange{Sequence: m.changeSequence, Epoch: m.epoch, Resync: !ok}
+	m.mtx.Unlock()
+	for _, change := range changes {
+		if err := stream.Send(change); err != nil {
+			return err
+		}
+	}
+	if err := stream.Send(caughtUp); err != nil {
+		return err
+	}
+	since = caughtUp.Sequence
+
+	ticker := time.NewTicker(WATCH_LEADER_CHECK_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// a deposed Raft leader no longer learns of commits
+			if !m.isLeader() {
+				return ERR_NOT_LEADER
+			}
+			continue
+		case <-changed:
+		}
+
+		m.mtx.Lock()
+		changes, ok := m.changeLogSince(user, since)
+		changed = m.changed
+		if !ok {
+			// the stream fell further behind than the log reaches
+			changes = []*FileChange{{Sequence: m.changeSequence, Epoch: m.epoch, Resync: true}}
+		}
+		m.mtx.Unlock()
+		for _, change := range changes {
+			if err := stream.Send(change); err != nil {
+				return err
+			}
+			since = change.Sequence
+		}
+	}
+}
+
+// Number an update that was just applied, add it to the change log, and
+// wake the change streams. Must hold m.mtx.
+func (m *MetaStore) recordChange(fileMetaData *FileMetaData) {
+	m.changeSequence++
+	m.changeSequences[fileMetaData.Filename] = m.changeSequence
+	m.changeLog = append(m.changeLog, &FileChange{
+		Sequence: m.changeSequence,
+		FileMetaData: &FileMetaData{
+			Filename:      fileMetaData.Filename,
+			Version:       fileMetaData.Version,
+			BlockHashList: fileMetaData.BlockHashList,
+		},
+	})
+	if len(m.changeLog) > CHANGE_LOG_LIMIT {
+		m.changeLogStart = m.changeLog[0].Sequence
+		m.changeLog = m.changeLog[1:]
+	}
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// Every update to the files user can see committed after sequence number
+// since, in order, or false if they are not all in the change log any
+// more, or since is ahead of the server. Must hold m.mtx.
+func (m *MetaStore) changeLogSince(user string, since int64) ([]*FileChange, bool) {
+	if since < m.changeLogStart || since > m.changeSequence {
+		return nil, false
+	}
+	changes := []*FileChange{}
+	start := sort.Search(len(m.changeLog), func(i int) bool {
+		return m.changeLog[i].Sequence > since
+	})
+	for _, change := range m.changeLog[start:] {
+		filename, ok := m.visibleName(user, change.FileMetaData.Filename)
+		if !ok {
+			continue
+		}
+		changes = append(changes, &FileChange{
+			Sequence: change.Sequence,
+			FileMetaData: &FileMetaData{
+				Filename:      filename,
+				Version:       change.FileMetaData.Version,
+				BlockHashList: change.FileMetaData.BlockHashList,
+			},
+		})
+	}
+	return changes, true
+}
+
+// Take the epoch the first entry of a Raft log names, so all servers of
+// the cluster share it
+func (m *MetaStore) setEpoch(epoch string) (proto.Message, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.epoch = epoch
+	return nil, nil
+}
+
+// A new name for the numbering of changes, for a MetaStore starting without
+// state
+func newEpoch() string {
+	epoch := make([]byte, 8)
+	if _, err := rand.Read(epoch); err != nil {
+		return time.Now().Format(time.RFC3339Nano)
+	}
+	return hex.EncodeToString(epoch)
+}
+
+// Number a change to the folders shared with user, which GetChangesSince
+// cannot describe file by file. Must hold m.mtx.
+func (m *MetaStore) recordShareChange(user string) {
+	m.changeSequence++
+	m.shareSequences[user] = m.changeSequence
+}
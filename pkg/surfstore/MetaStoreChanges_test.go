@@ -0,0 +1,115 @@
+package surfstore
+
+import (
+	context "context"
+	"fmt"
+	"testing"
+)
+
+func TestChangeLogSince(t *testing.T) {
+	m := NewMetaStore([]string{})
+	for _, update := range []struct {
+		filename string
+		version  int32
+	}{{"a", 1}, {"a", 2}, {"b", 1}, {"a", 3}} {
+		v, err := m.UpdateFile(context.Background(), &FileMetaData{Filename: update.filename, Version: update.version, BlockHashList: []string{"h"}})
+		if err != nil || v.Version != update.version {
+			t.Fatalf("update of %v to version %v: %v, %v", update.filename, update.version, v, err)
+		}
+	}
+
+	tests := []struct {
+		since    int64
+		ok       bool
+		versions []string
+	}{
+		{0, true, []string{"a1", "a2", "b1", "a3"}},
+		{2, true, []string{"b1", "a3"}},
+		{4, true, []string{}},
+		{5, false, nil},
+		{-1, false, nil},
+	}
+	for _, test := range tests {
+		changes, ok := m.changeLogSince("", test.since)
+		if ok != test.ok {
+			t.Errorf("since %v: ok %v, expected %v", test.since, ok, test.ok)
+			continue
+		}
+		versions := []string{}
+		for i, change := range changes {
+			versions = append(versions, fmt.Sprintf("%v%v", change.FileMetaData.Filename, change.FileMetaData.Version))
+			if change.Sequence != test.since+int64(i)+1 {
+				t.Errorf("since %v: change %v has sequence %v", test.since, i, change.Sequence)
+			}
+		}
+		if test.ok && len(versions) != len(test.versions) {
+			t.Errorf("since %v: got %v, expected %v", test.since, versions, test.versions)
+			continue
+		}
+		for i := range versions {
+			if versions[i] != test.versions[i] {
+				t.Errorf("since %v: got %v, expected %v", test.since, versions, test.versions)
+				break
+			}
+		}
+	}
+}
+
+func TestChangeLogDropsOldest(t *testing.T) {
+	m := NewMetaStore([]string{})
+	for version := int32(1); version <= int32(CHANGE_LOG_LIMIT)+2; version++ {
+		if _, err := m.UpdateFile(context.Background(), &FileMetaData{Filename: "f", Version: version, BlockHashList: []string{"h"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(m.changeLog) != CHANGE_LOG_LIMIT {
+		t.Fatalf("log holds %v changes, expected %v", len(m.changeLog), CHANGE_LOG_LIMIT)
+	}
+	if _, ok := m.changeLogSince("", 1); ok {
+		t.Errorf("changes after 1 reported although change 2 was dropped")
+	}
+	changes, ok := m.changeLogSince("", 2)
+	if !ok || len(changes) != CHANGE_LOG_LIMIT || changes[0].Sequence != 3 {
+		t.Errorf("changes after 2: %v changes, ok %v", len(changes), ok)
+	}
+}
+
+func TestGetChangesSincePages(t *testing.T) {
+	m := NewMetaStore([]string{})
+	for _, filename := range []string{"a", "a", "b", "c"} {
+		version := int32(1)
+		if current, ok := m.FileMetaMap[filename]; ok {
+			version = current.Version + 1
+		}
+		if _, err := m.UpdateFile(context.Background(), &FileMetaData{Filename: filename, Version: version, BlockHashList: []string{"h"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, epoch := range []string{"", "other"} {
+		changes, err := m.GetChangesSince(context.Background(), &ChangesRequest{Epoch: epoch, Cursor: 1})
+		if err != nil || !changes.Resync || changes.Epoch != m.epoch || changes.Cursor != 4 {
+			t.Errorf("epoch %q: %v, %v", epoch, changes, err)
+		}
+	}
+
+	// a is sent once, with its second version
+	pages := []struct {
+		filename string
+		version  int32
+		cursor   int64
+		hasMore  bool
+	}{{"a", 2, 2, true}, {"b", 1, 3, true}, {"c", 1, 4, false}}
+	cursor := int64(0)
+	for _, page := range pages {
+		changes, err := m.GetChangesSince(context.Background(), &ChangesRequest{Epoch: m.epoch, Cursor: cursor, Limit: 1})
+		if err != nil || changes.Resync {
+			t.Fatalf("cursor %v: %v, %v", cursor, changes, err)
+		}
+		if len(changes.Changes) != 1 || changes.Changes[0].Filename != page.filename || changes.Changes[0].Version != page.version ||
+			changes.Cursor != page.cursor || changes.HasMore != page.hasMore {
+			t.Errorf("cursor %v: got %v", cursor, changes)
+		}
+		cursor = changes.Cursor
+	}
+}
@@ -0,0 +1,117 @@
+package surfstore
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Maximum number of hashes sent in one DeleteBlocks call
+const GC_BATCH_SIZE int = 1000
+
+const DEFAULT_GC_GRACE_PERIOD = 10 * time.Minute
+
+// StartGarbageCollector collects unreferenced blocks every interval. Blocks
+// written or reported present to a client within GCGracePeriod are kept, so
+// that blocks uploaded for an update that has not reached the MetaStore yet
+// survive.
+func (m *MetaStore) StartGarbageCollector(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := m.collectGarbage(m.GCGracePeriod); err != nil {
+				log.Println("Garbage collection incomplete: ", err)
+			}
+		}
+	}()
+}
+
+// collectGarbage is a mark and sweep over the block servers: every hash
+// referenced by a version of a file the MetaStore keeps, or by a deleted
+// file still in the trash, is marked live, then every other block found on
+// a block server is deleted.
+func (m *MetaStore) collectGarbage(gracePeriod time.Duration) error {
+	m.maintenanceMtx.Lock()
+	defer m.maintenanceMtx.Unlock()
+	if !m.isLeader() {
+		return nil
+	}
+
+	m.mtx.Lock()
+	live := m.liveBlocks()
+	servers := append([]string{}, m.BlockStoreAddrs...)
+	for addr := range m.retiredAddrs {
+		servers = append(servers, addr)
+	}
+	m.mtx.Unlock()
+
+	client := m.maintenanceClient()
+	defer client.Close()
+	collected := 0
+	var lastErr error
+	for _, addr := range servers {
+		var hashes []string
+		if err := client.ListBlockHashes(addr, &hashes); err != nil {
+			lastErr = fmt.Errorf("listing blocks on %v: %v", addr, err)
+			log.Println(lastErr)
+			continue
+		}
+		garbage := []string{}
+		for _, hash := range hashes {
+			if !live[hash] {
+				garbage = append(garbage, hash)
+			}
+		}
+		deleted, err := deleteBlocks(client, garbage, gracePeriod, addr)
+		if err != nil {
+			lastErr = fmt.Errorf("deleting blocks on %v: %v", addr, err)
+			log.Println(lastErr)
+		}
+		collected += deleted
+	}
+	log.Printf("garbage collection deleted %v blocks from %v servers\n", collected, len(servers))
+	return lastErr
+}
+
+// Hashes of all blocks still referenced. Must hold m.mtx.
+func (m *MetaStore) liveBlocks() map[string]bool {
+	live := map[string]bool{}
+	for _, fileMetaData := range m.FileMetaMap {
+		for _, hash := range fileMetaData.BlockHashList {
+			live[hash] = true
+		}
+	}
+	for _, versions := range m.history {
+		for _, fileMetaData := range versions {
+			for _, hash := range fileMetaData.BlockHashList {
+				live[hash] = true
+			}
+		}
+	}
+	now := time.Now().UnixMilli()
+	for _, trashed := range m.trash {
+		if !m.trashExpired(trashed, now) {
+			for _, hash := range trashed.FileMetaData.BlockHashList {
+				live[hash] = true
+			}
+		}
+	}
+	return live
+}
+
+// Delete hashes from a block server in batches, returning how many blocks
+// were deleted
+func deleteBlocks(client RPCClient, hashes []string, gracePeriod time.Duration, addr string) (int, error) {
+	deleted := 0
+	for start := 0; start < len(hashes); start += GC_BATCH_SIZE {
+		end := start + GC_BATCH_SIZE
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		var out []string
+		if err := client.DeleteBlocks(hashes[start:end], gracePeriod, addr, &out); err != nil {
+			return deleted, err
+		}
+		deleted += len(out)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,55 @@
+package surfstore
+
+import (
+	context "context"
+	"testing"
+	"time"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	tests := []struct {
+		name         string
+		historyLimit int
+		kept         []string
+	}{
+		// the deleted file's blocks stay while it is in the trash
+		{"no history", 0, []string{"a3", "b1", "recent"}},
+		{"history", 1, []string{"a2", "a3", "b1", "recent"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blockStore := NewBlockStore()
+			m := NewMetaStore([]string{startTestBlockStore(t, blockStore)})
+			m.HistoryLimit = test.historyLimit
+			update := func(filename string, version int32, hash string) {
+				if _, err := m.UpdateFile(context.Background(), &FileMetaData{Filename: filename, Version: version, BlockHashList: []string{hash}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			hashes := map[string]string{}
+			for _, data := range []string{"a1", "a2", "a3", "b1", "recent", "stale"} {
+				hashes[data] = putTestBlock(t, blockStore, data)
+			}
+			// a block of an upload whose update has not reached the MetaStore
+			blockStore.touch(hashes["recent"])
+			for i, data := range []string{"a1", "a2", "a3"} {
+				update("a.txt", int32(i+1), hashes[data])
+			}
+			update("b.txt", 1, hashes["b1"])
+			update("b.txt", 2, TOMBSTONE_HASHVALUE)
+
+			if err := m.collectGarbage(time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			kept := map[string]bool{}
+			for _, data := range test.kept {
+				kept[data] = true
+			}
+			for data, hash := range hashes {
+				if has, err := blockStore.Backend.Has(hash); err != nil || has != kept[data] {
+					t.Errorf("block %v stored: %v, %v; expected %v", data, has, err, kept[data])
+				}
+			}
+		})
+	}
+}
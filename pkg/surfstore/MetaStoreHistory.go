@@ -0,0 +1,94 @@
+package surfstore
+
+import (
+	context "context"
+	"sort"
+	"strings"
+
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// Number of earlier versions of each file the MetaStore keeps by default
+const DEFAULT_HISTORY_LIMIT int = 10
+
+// Returns every version kept of the file at path, or of each file below it
+// if it is a directory, ordered by filename and oldest first. The last
+// version of each file is its current one. An empty path lists all the
+// caller's files, without the files shared with it.
+func (m *MetaStore) GetFileVersions(ctx context.Context, request *VersionsRequest) (*FileVersions, error) {
+	user := UserFromContext(ctx)
+	path := strings.Trim(request.Path, "/")
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	versions := []*FileMetaData{}
+	for key := range m.FileMetaMap {
+		filename, ok := m.visibleName(user, key)
+		if !ok || (path == "" && isSharedName(filename)) {
+			continue
+		}
+		if path != "" && filename != path && !strings.HasPrefix(filename, path+"/") {
+			continue
+		}
+		for _, fileMetaData := range m.versions(key) {
+			versions = append(versions, &FileMetaData{
+				Filename:      filename,
+				Version:       fileMetaData.Version,
+				BlockHashList: fileMetaData.BlockHashList,
+				CommittedAt:   fileMetaData.CommittedAt,
+			})
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].Filename != versions[j].Filename {
+			return versions[i].Filename < versions[j].Filename
+		}
+		return versions[i].Version < versions[j].Version
+	})
+	return &FileVersions{Versions: versions}, nil
+}
+
+// Returns the given version of a file, if it is still kept
+func (m *MetaStore) GetFileVersion(ctx context.Context, request *VersionRequest) (*FileMetaData, error) {
+	user := UserFromContext(ctx)
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if key, ok := m.readableKey(user, request.Filename); ok {
+		for _, fileMetaData := range m.versions(key) {
+			if fileMetaData.Version == request.Version {
+				return &FileMetaData{
+					Filename:      request.Filename,
+					Version:       fileMetaData.Version,
+					BlockHashList: fileMetaData.BlockHashList,
+					CommittedAt:   fileMetaData.CommittedAt,
+				}, nil
+			}
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "version %v of %v is not kept", request.Version, request.Filename)
+}
+
+// The versions kept of a file, oldest first, ending with the current one.
+// Must hold m.mtx.
+func (m *MetaStore) versions(key string) []*FileMetaData {
+	current, ok := m.FileMetaMap[key]
+	if !ok {
+		return nil
+	}
+	versions := append([]*FileMetaData{}, m.history[key]...)
+	return append(versions, current)
+}
+
+// Add a version that was just replaced to the history of a file, dropping
+// the oldest ones beyond HistoryLimit. Must hold m.mtx.
+func (m *MetaStore) keepVersion(key string, fileMetaData *FileMetaData) {
+	if m.HistoryLimit <= 0 {
+		delete(m.history, key)
+		return
+	}
+	versions := append(m.history[key], fileMetaData)
+	if len(versions) > m.HistoryLimit {
+		versions = append([]*FileMetaData{}, versions[len(versions)-m.HistoryLimit:]...)
+	}
+	m.history[key] = versions
+}
@@ -0,0 +1,174 @@
+package surfstore
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Time to wait after a rebalance is requested before starting it, so that a
+// burst of membership changes is handled by a single pass
+const REBALANCE_DELAY = time.Second
+
+// Time to wait before retrying a rebalance that could not finish
+const REBALANCE_RETRY_INTERVAL = 10 * time.Second
+
+// Ask for the blocks to be moved to the servers the ring makes responsible
+// for them. Requests made while a rebalance is pending are merged.
+func (m *MetaStore) requestRebalance() {
+	select {
+	case m.rebalanceCh <- struct{}{}:
+	default:
+	}
+}
+
+func (m *MetaStore) rebalanceLoop() {
+	for range m.rebalanceCh {
+		time.Sleep(REBALANCE_DELAY)
+		if err := m.rebalance(); err != nil {
+			log.Println("Rebalance incomplete, retrying: ", err)
+			time.AfterFunc(REBALANCE_RETRY_INTERVAL, m.requestRebalance)
+		}
+	}
+}
+
+// rebalance makes sure every block stored on the cluster is on each of the
+// servers responsible for it. Only blocks missing from a responsible server
+// are copied, so a membership change moves just the blocks whose place on
+// the ring changed. Blocks are read from current members as well as from
+// servers removed since the last complete pass.
+func (m *MetaStore) rebalance() error {
+	m.maintenanceMtx.Lock()
+	defer m.maintenanceMtx.Unlock()
+	if !m.isLeader() {
+		return nil
+	}
+
+	m.mtx.Lock()
+	members := append([]string{}, m.BlockStoreAddrs...)
+	sources := append([]string{}, m.BlockStoreAddrs...)
+	retired := []string{}
+	for addr := range m.retiredAddrs {
+		sources = append(sources, addr)
+		retired = append(retired, addr)
+	}
+	ring := m.ConsistentHashRing
+	replicationFactor := m.ReplicationFactor
+	gracePeriod := m.GCGracePeriod
+	m.mtx.Unlock()
+
+	client := m.maintenanceClient()
+	defer client.Close()
+	holders := map[string][]string{}
+	var lastErr error
+	for _, addr := range sources {
+		var hashes []string
+		// keep going so the blocks on reachable servers are still moved
+		if err := client.ListBlockHashes(addr, &hashes); err != nil {
+			lastErr = fmt.Errorf("listing blocks on %v: %v", addr, err)
+			log.Println(lastErr)
+			continue
+		}
+		for _, hash := range hashes {
+			holders[hash] = append(holders[hash], addr)
+		}
+	}
+
+	moved := 0
+	misplaced := map[string][]string{}
+	for hash, holderAddrs := range holders {
+		owners := ring.GetResponsibleServers(hash, replicationFactor)
+		copied := true
+		for _, owner := range owners {
+			if contains(holderAddrs, owner) {
+				continue
+			}
+			if err := copyBlock(client, hash, holderAddrs, owner); err != nil {
+				log.Println("Failed to move block: ", hash, err)
+				lastErr = err
+				copied = false
+				continue
+			}
+			moved++
+		}
+		// other copies can only go once every owner has the block
+		if !copied {
+			continue
+		}
+		for _, addr := range holderAddrs {
+			if !contains(owners, addr) {
+				misplaced[addr] = append(misplaced[addr], hash)
+			}
+		}
+	}
+
+	// copies a client wrote or checked within the grace period may belong to
+	// an upload still in progress, so like the garbage collector we leave
+	// them and come back once the grace period has passed
+	removed, kept := 0, 0
+	for addr, hashes := range misplaced {
+		deleted, err := deleteBlocks(client, hashes, gracePeriod, addr)
+		if err != nil {
+			log.Println("Failed to delete moved blocks: ", addr, err)
+			lastErr = err
+		} else {
+			kept += len(hashes) - deleted
+		}
+		removed += deleted
+	}
+	log.Printf("rebalance copied %v blocks and deleted %v misplaced copies across %v servers\n", moved, removed, len(members))
+	if kept > 0 {
+		log.Printf("rebalance kept %v recently used misplaced copies until the grace period passes\n", kept)
+		time.AfterFunc(gracePeriod, m.requestRebalance)
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+
+	// everything on the retired servers now lives on the members
+	m.mtx.Lock()
+	for _, addr := range retired {
+		if !m.isBlockStore(addr) {
+			delete(m.retiredAddrs, addr)
+		}
+	}
+	m.mtx.Unlock()
+	return nil
+}
+
+// Client for the calls the rebalancer and garbage collector make to block
+// servers. They list or delete up to a whole server's blocks at a time, so
+// every call, streams included, gets MAINTENANCE_RPC_TIMEOUT.
+func (m *MetaStore) maintenanceClient() RPCClient {
+	return RPCClient{
+		DialOption: m.DialOption,
+		Token:      m.Token,
+		Timeouts:   map[string]time.Duration{"": MAINTENANCE_RPC_TIMEOUT, "ListBlockHashes": MAINTENANCE_RPC_TIMEOUT},
+		conns:      newConnPool(),
+	}
+}
+
+// Copy a block from the first of sources that has it to dest
+func copyBlock(client RPCClient, hash string, sources []string, dest string) error {
+	var block Block
+	if err := getBlockFromReplicas(client, hash, sources, &block); err != nil {
+		return err
+	}
+	var succ bool
+	if err := client.PutBlock(&block, dest, &succ); err != nil {
+		return err
+	}
+	if !succ {
+		return fmt.Errorf("block server %v refused block %v", dest, hash)
+	}
+	return nil
+}
+
+func contains(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
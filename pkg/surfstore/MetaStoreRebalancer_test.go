@@ -0,0 +1,122 @@
+package surfstore
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	grpc "google.golang.org/grpc"
+)
+
+// Serve blockStore alone on a local port, and return its address. Its
+// blocks count as last touched an hour ago unless touched since.
+func startTestBlockStore(t *testing.T, blockStore *BlockStore) string {
+	blockStore.started = time.Now().Add(-time.Hour)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	RegisterBlockStoreServer(server, blockStore)
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+	return l.Addr().String()
+}
+
+// Put a block on a block server without touching it
+func putTestBlock(t *testing.T, blockStore *BlockStore, data string) string {
+	hash := GetBlockHashString([]byte(data))
+	if err := blockStore.Backend.Put(hash, &Block{BlockData: []byte(data), BlockSize: int32(len(data))}); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestRebalanceMovesOnlyReassignedBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(addrs []string) *BlockStoreAddr
+	}{
+		{"add server", func(addrs []string) *BlockStoreAddr { return &BlockStoreAddr{Addr: addrs[2], Weight: 1} }},
+		{"change weight", func(addrs []string) *BlockStoreAddr { return &BlockStoreAddr{Addr: addrs[0], Weight: 4} }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blockStores := map[string]*BlockStore{}
+			addrs := []string{}
+			for i := 0; i < 3; i++ {
+				blockStore := NewBlockStore()
+				addr := startTestBlockStore(t, blockStore)
+				blockStores[addr] = blockStore
+				addrs = append(addrs, addr)
+			}
+			m := NewMetaStore(addrs[:2])
+			// enough virtual nodes that any change moves some blocks
+			m.ConsistentHashRing = NewWeightedConsistentHashRing(addrs[:2], nil, 16)
+			m.GCGracePeriod = time.Minute
+
+			// every block starts on its owner; one in four was just used
+			before := map[string]string{}
+			fresh := map[string]bool{}
+			for i := 0; i < 200; i++ {
+				data := fmt.Sprint("block ", i)
+				owner := m.ConsistentHashRing.GetResponsibleServer(GetBlockHashString([]byte(data)))
+				hash := putTestBlock(t, blockStores[owner], data)
+				before[hash] = owner
+				if i%4 == 0 {
+					blockStores[owner].touch(hash)
+					fresh[hash] = true
+				}
+			}
+
+			if succ, err := m.addBlockStore(test.change(addrs)); err != nil || !succ.Flag {
+				t.Fatalf("ring change: %v, %v", succ, err)
+			}
+			if err := m.rebalance(); err != nil {
+				t.Fatal(err)
+			}
+			moved := 0
+			for hash, owner := range before {
+				after := m.ConsistentHashRing.GetResponsibleServer(hash)
+				if after != owner {
+					moved++
+				}
+				for _, addr := range addrs {
+					has, err := blockStores[addr].Backend.Has(hash)
+					if err != nil {
+						t.Fatal(err)
+					}
+					// the old copy stays within the grace period
+					expected := addr == after || (addr == owner && fresh[hash])
+					if has != expected {
+						t.Errorf("block owned by %v, then %v, fresh %v: on %v is %v", owner, after, fresh[hash], addr, has)
+					}
+				}
+			}
+			if moved == 0 || moved == len(before) {
+				t.Fatalf("%v of %v blocks moved", moved, len(before))
+			}
+
+			// once the grace period passed, the old copies go too
+			for _, blockStore := range blockStores {
+				blockStore.touchMtx.Lock()
+				for hash := range fresh {
+					blockStore.touched[hash] = time.Now().Add(-time.Hour)
+				}
+				blockStore.touchMtx.Unlock()
+			}
+			if err := m.rebalance(); err != nil {
+				t.Fatal(err)
+			}
+			for hash := range fresh {
+				after := m.ConsistentHashRing.GetResponsibleServer(hash)
+				for _, addr := range addrs {
+					if has, _ := blockStores[addr].Backend.Has(hash); has != (addr == after) {
+						t.Errorf("block owned by %v after the grace period: on %v is %v", after, addr, has)
+					}
+				}
+			}
+		})
+	}
+}
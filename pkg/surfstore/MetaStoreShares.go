@@ -0,0 +1,212 @@
+package surfstore
+
+import (
+	context "context"
+	"log"
+	"sort"
+	"strings"
+
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Folders shared with a user appear in the user's namespace below this
+// directory, as SHARED_DIR/<owner>/<path>
+const SHARED_DIR string = "@shared"
+
+// Grant a user read or read-write access to a folder (or file) of the
+// caller, or change the access granted. Everything below the path is
+// shared.
+func (m *MetaStore) ShareFolder(ctx context.Context, share *Share) (*Success, error) {
+	return m.share(ownedShare(ctx, share))
+}
+
+// Take back the access to a folder granted with ShareFolder
+func (m *MetaStore) UnshareFolder(ctx context.Context, share *Share) (*Success, error) {
+	return m.unshare(ownedShare(ctx, share))
+}
+
+// Returns the shares the caller granted and those granted to the caller
+func (m *MetaStore) GetShares(ctx context.Context, _ *emptypb.Empty) (*Shares, error) {
+	user := UserFromContext(ctx)
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	shares := []*Share{}
+	for _, share := range m.shares {
+		if share.Owner == user || share.Grantee == user {
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return shareKey(shares[i]) < shareKey(shares[j])
+	})
+	return &Shares{Shares: shares}, nil
+}
+
+// Copy of a share request from a client, owned by the client
+func ownedShare(ctx context.Context, share *Share) *Share {
+	return &Share{
+		Owner:      UserFromContext(ctx),
+		Path:       share.Path,
+		Grantee:    share.Grantee,
+		Permission: share.Permission,
+	}
+}
+
+// Sharing needs authenticated users on both ends, and a path that is one
+// of the owner's own
+func (m *MetaStore) share(share *Share) (*Success, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if !isValidUser(share.Owner) || !isValidUser(share.Grantee) || share.Owner == share.Grantee ||
+		!isValidSharePath(share.Path) || (share.Permission != Permission_READ && share.Permission != Permission_READ_WRITE) {
+		return &Success{Flag: false}, nil
+	}
+	if err := m.logEntry(&LogEntry{Share: share}); err != nil {
+		log.Println("Failed to log share: ", err)
+		return nil, err
+	}
+	m.shares[shareKey(share)] = share
+	m.recordShareChange(share.Grantee)
+	m.maybeSnapshot()
+	return &Success{Flag: true}, nil
+}
+
+func (m *MetaStore) unshare(share *Share) (*Success, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if _, ok := m.shares[shareKey(share)]; !ok {
+		return &Success{Flag: false}, nil
+	}
+	if err := m.logEntry(&LogEntry{Unshare: share}); err != nil {
+		log.Println("Failed to log unshare: ", err)
+		return nil, err
+	}
+	delete(m.shares, shareKey(share))
+	m.recordShareChange(share.Grantee)
+	m.maybeSnapshot()
+	return &Success{Flag: true}, nil
+}
+
+func shareKey(share *Share) string {
+	return share.Owner + NAMESPACE_SEPARATOR + share.Path + NAMESPACE_SEPARATOR + share.Grantee
+}
+
+func isValidSharePath(path string) bool {
+	if strings.Contains(path, NAMESPACE_SEPARATOR) || isSharedName(path) {
+		return false
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// Whether filename lies in the directory holding folders shared with a
+// user
+func isSharedName(filename string) bool {
+	return filename == SHARED_DIR || strings.HasPrefix(filename, SHARED_DIR+"/")
+}
+
+// Split SHARED_DIR/<owner>/<path> into owner and path
+func splitSharedName(filename string) (owner string, path string, ok bool) {
+	parts := strings.SplitN(filename, "/", 3)
+	if len(parts) < 3 || parts[0] != SHARED_DIR {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// Whether path is, or lies below, the path of share
+func shareCovers(share *Share, path string) bool {
+	return path == share.Path || strings.HasPrefix(path, share.Path+"/")
+}
+
+// Access grantee has to path in owner's namespace. Must hold m.mtx.
+func (m *MetaStore) permission(owner string, path string, grantee string) Permission {
+	permission := Permission_NO_ACCESS
+	for _, share := range m.shares {
+		if share.Owner == owner && share.Grantee == grantee && shareCovers(share, path) && share.Permission > permission {
+			permission = share.Permission
+		}
+	}
+	return permission
+}
+
+// Name under which user sees the file with the given MetaStore key: its
+// plain name in the user's own namespace, or its name below SHARED_DIR if
+// it is shared with the user. Must hold m.mtx.
+func (m *MetaStore) visibleName(user string, key string) (string, bool) {
+	owner, filename := splitNamespacedName(key)
+	if owner == user {
+		return filename, true
+	}
+	if user == "" || m.permission(owner, filename, user) == Permission_NO_ACCESS {
+		return "", false
+	}
+	return SHARED_DIR + "/" + owner + "/" + filename, true
+}
+
+// MetaStore key of a file user can read, named as user sees it. Must hold
+// m.mtx.
+func (m *MetaStore) readableKey(user string, filename string) (string, bool) {
+	if strings.Contains(filename, NAMESPACE_SEPARATOR) {
+		return "", false
+	}
+	if user != "" && isSharedName(filename) {
+		owner, path, ok := splitSharedName(filename)
+		if !ok || m.permission(owner, path, user) == Permission_NO_ACCESS {
+			return "", false
+		}
+		return namespacedName(owner, path), true
+	}
+	return namespacedName(user, filename), true
+}
+
+// Add the files shared with user to its FileInfoMap, below SHARED_DIR. The
+// directories leading to each shared folder are listed too, so clients can
+// create them like any other directory. Must hold m.mtx.
+func (m *MetaStore) addSharedFiles(user string, fileInfoMap map[string]*FileMetaData) {
+	granted := map[string][]*Share{}
+	for _, share := range m.shares {
+		if share.Grantee == user {
+			granted[share.Owner] = append(granted[share.Owner], share)
+		}
+	}
+	if len(granted) == 0 {
+		return
+	}
+
+	addDirectory := func(filename string) {
+		if _, ok := fileInfoMap[filename]; !ok {
+			fileInfoMap[filename] = &FileMetaData{Filename: filename, Version: 1, BlockHashList: []string{DIRECTORY_HASHVALUE}}
+		}
+	}
+	addDirectory(SHARED_DIR)
+	for owner, shares := range granted {
+		ownerDir := SHARED_DIR + "/" + owner
+		addDirectory(ownerDir)
+		for _, share := range shares {
+			parts := strings.Split(share.Path, "/")
+			for i := 1; i < len(parts); i++ {
+				addDirectory(ownerDir + "/" + strings.Join(parts[:i], "/"))
+			}
+		}
+	}
+
+	for key, fileMetaData := range m.FileMetaMap {
+		owner, path := splitNamespacedName(key)
+		for _, share := range granted[owner] {
+			if shareCovers(share, path) {
+				filename := SHARED_DIR + "/" + owner + "/" + path
+				fileInfoMap[filename] = &FileMetaData{
+					Filename:      filename,
+					Version:       fileMetaData.Version,
+					BlockHashList: fileMetaData.BlockHashList,
+				}
+				break
+			}
+		}
+	}
+}
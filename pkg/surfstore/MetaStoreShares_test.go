@@ -0,0 +1,201 @@
+package surfstore
+
+import (
+	context "context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Context of a call the Authenticator found to be made by user
+func userContext(user string) context.Context {
+	return context.WithValue(context.Background(), userContextKey{}, user)
+}
+
+func sharedFiles(t *testing.T, m *MetaStore, user string, owner string) []string {
+	fileInfoMap, err := m.GetFileInfoMap(userContext(user), &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{}
+	for filename := range fileInfoMap.FileInfoMap {
+		if strings.HasPrefix(filename, SHARED_DIR+"/"+owner+"/") {
+			files = append(files, filename)
+		}
+	}
+	return files
+}
+
+func shareTestStore(t *testing.T) *MetaStore {
+	m := NewMetaStore([]string{})
+	for _, filename := range []string{"docs/a.txt", "private/b.txt"} {
+		if _, err := m.UpdateFile(userContext("alice"), &FileMetaData{Filename: filename, Version: 1, BlockHashList: []string{"h"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestShareFolderAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		grantee    string
+		permission Permission
+		visible    []string
+		writable   bool
+	}{
+		{"read", "bob", Permission_READ, []string{"@shared/alice/docs", "@shared/alice/docs/a.txt"}, false},
+		{"read write", "bob", Permission_READ_WRITE, []string{"@shared/alice/docs", "@shared/alice/docs/a.txt"}, true},
+		{"other user", "carol", Permission_READ_WRITE, []string{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := shareTestStore(t)
+			if _, err := m.UpdateFile(userContext("alice"), &FileMetaData{Filename: "docs", Version: 1, BlockHashList: []string{DIRECTORY_HASHVALUE}}); err != nil {
+				t.Fatal(err)
+			}
+			if succ, err := m.ShareFolder(userContext("alice"), &Share{Path: "docs", Grantee: test.grantee, Permission: test.permission}); err != nil || !succ.Flag {
+				t.Fatalf("share: %v, %v", succ, err)
+			}
+
+			files := sharedFiles(t, m, "bob", "alice")
+			if len(files) != len(test.visible) {
+				t.Errorf("bob sees %v, expected %v", files, test.visible)
+			}
+			for _, filename := range test.visible {
+				found := false
+				for _, file := range files {
+					found = found || file == filename
+				}
+				if !found {
+					t.Errorf("bob does not see %v", filename)
+				}
+			}
+
+			_, err := m.UpdateFile(userContext("bob"), &FileMetaData{Filename: "@shared/alice/docs/a.txt", Version: 2, BlockHashList: []string{"h2"}})
+			if test.writable && err != nil {
+				t.Errorf("update with write access: %v", err)
+			} else if !test.writable && status.Code(err) != codes.PermissionDenied {
+				t.Errorf("update without write access: %v", err)
+			}
+			if _, err := m.UpdateFile(userContext("bob"), &FileMetaData{Filename: "@shared/alice/private/b.txt", Version: 2, BlockHashList: []string{"h2"}}); status.Code(err) != codes.PermissionDenied {
+				t.Errorf("update outside the shared folder: %v", err)
+			}
+			version := int32(1)
+			if test.writable {
+				version = 2
+			}
+			if fileMetaData := m.FileMetaMap[namespacedName("alice", "docs/a.txt")]; fileMetaData.Version != version {
+				t.Errorf("shared file at version %v, expected %v", fileMetaData.Version, version)
+			}
+		})
+	}
+}
+
+func TestUnshareFolderRevokesAccess(t *testing.T) {
+	m := shareTestStore(t)
+	share := &Share{Path: "docs", Grantee: "bob", Permission: Permission_READ_WRITE}
+	if _, err := m.ShareFolder(userContext("alice"), share); err != nil {
+		t.Fatal(err)
+	}
+	// an update accepted before the share was taken back, and applied after
+	entry, err := m.updateEntry(userContext("bob"), &FileMetaData{Filename: "@shared/alice/docs/a.txt", Version: 2, BlockHashList: []string{"h2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if succ, err := m.UnshareFolder(userContext("alice"), share); err != nil || !succ.Flag {
+		t.Fatalf("unshare: %v, %v", succ, err)
+	}
+
+	if files := sharedFiles(t, m, "bob", "alice"); len(files) != 0 {
+		t.Errorf("bob still sees %v", files)
+	}
+	if _, err := m.applyEntry(context.Background(), entry); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("update applied after unsharing: %v", err)
+	}
+	if _, err := m.UpdateFile(userContext("bob"), &FileMetaData{Filename: "@shared/alice/docs/a.txt", Version: 2, BlockHashList: []string{"h2"}}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("update after unsharing: %v", err)
+	}
+	if fileMetaData := m.FileMetaMap[namespacedName("alice", "docs/a.txt")]; fileMetaData.Version != 1 {
+		t.Errorf("shared file at version %v, expected 1", fileMetaData.Version)
+	}
+}
+
+// Serve a MetaStore and BlockStore on one local port to the users of
+// tokens, which are named after their tokens, and return its address
+func startAuthTestServer(t *testing.T, tokens ...string) string {
+	usersFile := filepath.Join(t.TempDir(), "users")
+	users := ""
+	for _, token := range tokens {
+		users += token + " " + token + "\n"
+	}
+	if err := os.WriteFile(usersFile, []byte(users), 0600); err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := NewAuthenticator(usersFile, false, "server-token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(authenticator.UnaryInterceptor), grpc.StreamInterceptor(authenticator.StreamInterceptor))
+	RegisterMetaStoreServer(server, NewMetaStore([]string{l.Addr().String()}))
+	RegisterBlockStoreServer(server, NewBlockStore())
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+	return l.Addr().String()
+}
+
+func TestSyncRestoresRejectedSharedDeletions(t *testing.T) {
+	addr := startAuthTestServer(t, "alice", "bob")
+	alice := newTestClient(t, addr, "alice")
+	alice.Token = "alice"
+	bob := newTestClient(t, addr, "bob")
+	bob.Token = "bob"
+
+	if err := os.Mkdir(filepath.Join(alice.BaseDir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, alice, "docs/a.txt", "shared")
+	syncTestClient(t, alice)
+	var succ bool
+	if err := alice.ShareFolder("docs", "bob", Permission_READ, &succ); err != nil || !succ {
+		t.Fatalf("share: %v, %v", succ, err)
+	}
+	syncTestClient(t, bob)
+
+	// neither the read-only folder nor the directories leading to it can be
+	// deleted on the server, so they come back
+	if err := os.RemoveAll(filepath.Join(bob.BaseDir, SHARED_DIR)); err != nil {
+		t.Fatal(err)
+	}
+	syncTestClient(t, bob)
+	data, err := os.ReadFile(filepath.Join(bob.BaseDir, SHARED_DIR, "alice", "docs", "a.txt"))
+	if err != nil || string(data) != "shared" {
+		t.Fatalf("shared file restored as %q, %v", data, err)
+	}
+
+	// and stay, with nothing left to send
+	syncTestClient(t, bob)
+	index, err := LoadMetaFromMetaFile(bob.BaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{SHARED_DIR, SHARED_DIR + "/alice", SHARED_DIR + "/alice/docs", SHARED_DIR + "/alice/docs/a.txt"} {
+		if index[filename] == nil || isTombstone(index[filename]) {
+			t.Errorf("%v indexed as %v", filename, index[filename])
+		}
+		if _, err := os.Stat(filepath.Join(bob.BaseDir, filepath.FromSlash(filename))); err != nil {
+			t.Errorf("%v not restored: %v", filename, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package surfstore
+
+import (
+	"container/heap"
+	context "context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// How long the MetaStore keeps deleted files in the trash by default
+const DEFAULT_TRASH_RETENTION = 30 * 24 * time.Hour
+
+// Returns the deleted files in the trash at path, or below it if it is a
+// directory, ordered by filename, each in the last version it had before
+// it was deleted. An empty path lists all the caller's deleted files,
+// without those in folders shared with it.
+func (m *MetaStore) GetTrash(ctx context.Context, request *TrashRequest) (*Trash, error) {
+	user := UserFromContext(ctx)
+	path := strings.Trim(request.Path, "/")
+	now := time.Now().UnixMilli()
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	files := []*TrashedFile{}
+	for key, trashed := range m.trash {
+		filename, ok := m.visibleName(user, key)
+		if !ok || m.trashExpired(trashed, now) || (path == "" && isSharedName(filename)) {
+			continue
+		}
+		if path != "" && filename != path && !strings.HasPrefix(filename, path+"/") {
+			continue
+		}
+		files = append(files, visibleTrashedFile(filename, trashed.FileMetaData, trashed.DeletedAt))
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].FileMetaData.Filename < files[j].FileMetaData.Filename
+	})
+	return &Trash{Files: files}, nil
+}
+
+// Bring back the deleted file at path, or everything deleted below it, in
+// the version it had before it was deleted, along with the deleted
+// directories leading to it. Files in folders shared with the caller need
+// read-write access. Returns the files undeleted in their new version.
+func (m *MetaStore) Undelete(ctx context.Context, request *TrashRequest) (*Trash, error) {
+	return m.undelete(undeleteEntry(ctx, request))
+}
+
+// Undelete request of the caller, stamped with the time it arrived
+func undeleteEntry(ctx context.Context, request *TrashRequest) *UndeleteEntry {
+	return &UndeleteEntry{
+		User:        UserFromContext(ctx),
+		Path:        strings.Trim(request.Path, "/"),
+		RequestedAt: time.Now().UnixMilli(),
+	}
+}
+
+// Everything is decided from the entry and the replicated state, so the
+// entry undeletes the same files wherever it is applied
+func (m *MetaStore) undelete(entry *UndeleteEntry) (*Trash, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	path := entry.Path
+	if path == "" || strings.Contains(path, NAMESPACE_SEPARATOR) {
+		return &Trash{Files: []*TrashedFile{}}, nil
+	}
+
+	filenames := map[string]string{}
+	keys := []string{}
+	for key, trashed := range m.trash {
+		filename, ok := m.visibleName(entry.User, key)
+		if !ok || m.trashExpired(trashed, entry.RequestedAt) {
+			continue
+		}
+		if filename != path && !strings.HasPrefix(filename, path+"/") && !strings.HasPrefix(path, filename+"/") {
+			continue
+		}
+		if owner, ownerPath := splitNamespacedName(key); owner != entry.User && m.permission(owner, ownerPath, entry.User) != Permission_READ_WRITE {
+			continue
+		}
+		filenames[key] = filename
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return &Trash{Files: []*TrashedFile{}}, nil
+	}
+	if err := m.logEntry(&LogEntry{Undelete: entry}); err != nil {
+		log.Println("Failed to log undelete: ", err)
+		return nil, err
+	}
+
+	// directories before the files in them
+	sort.Slice(keys, func(i, j int) bool {
+		return filenames[keys[i]] < filenames[keys[j]]
+	})
+	files := []*TrashedFile{}
+	for _, key := range keys {
+		trashed := m.trash[key]
+		fileMetaData := &FileMetaData{
+			Filename:      key,
+			Version:       m.FileMetaMap[key].Version + 1,
+			BlockHashList: trashed.FileMetaData.BlockHashList,
+			CommittedAt:   entry.RequestedAt,
+		}
+		m.applyUpdate(fileMetaData)
+		files = append(files, visibleTrashedFile(filenames[key], fileMetaData, trashed.DeletedAt))
+	}
+	m.maybeSnapshot()
+	return &Trash{Files: files}, nil
+}
+
+// Put the version a deletion replaces in the trash, and take a file that
+// is written again out of it. Must hold m.mtx.
+func (m *MetaStore) trashVersion(key string, current *FileMetaData, update *FileMetaData) {
+	if !isTombstone(update) {
+		delete(m.trash, key)
+	} else if !isTombstone(current) && m.TrashRetention > 0 {
+		trashed := &TrashedFile{FileMetaData: current, DeletedAt: update.CommittedAt}
+		m.trash[key] = trashed
+		heap.Push(&m.trashQueue, trashed)
+	}
+}
+
+// Drop the files deleted longer than TrashRetention before now, the time
+// an update arrived, so every replica drops the same ones. Only the files
+// that expired are looked at. Must hold m.mtx.
+func (m *MetaStore) purgeTrash(now int64) {
+	if now <= 0 {
+		return
+	}
+	for len(m.trashQueue) > 0 && m.trashExpired(m.trashQueue[0], now) {
+		trashed := heap.Pop(&m.trashQueue).(*TrashedFile)
+		// unless the file was written again, or deleted again since
+		if key := trashed.FileMetaData.Filename; m.trash[key] == trashed {
+			delete(m.trash, key)
+		}
+	}
+}
+
+// Deleted files, oldest deletion first. A file written again stays in it
+// until it would have expired.
+type trashQueue []*TrashedFile
+
+func (q trashQueue) Len() int           { return len(q) }
+func (q trashQueue) Less(i, j int) bool { return q[i].DeletedAt < q[j].DeletedAt }
+func (q trashQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *trashQueue) Push(x interface{}) {
+	*q = append(*q, x.(*TrashedFile))
+}
+
+func (q *trashQueue) Pop() interface{} {
+	old := *q
+	trashed := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return trashed
+}
+
+// Whether a deleted file is past TrashRetention at now, in milliseconds
+func (m *MetaStore) trashExpired(trashed *TrashedFile, now int64) bool {
+	return trashed.DeletedAt+m.TrashRetention.Milliseconds() <= now
+}
+
+func visibleTrashedFile(filename string, fileMetaData *FileMetaData, deletedAt int64) *TrashedFile {
+	return &TrashedFile{
+		FileMetaData: &FileMetaData{
+			Filename:      filename,
+			Version:       fileMetaData.Version,
+			BlockHashList: fileMetaData.BlockHashList,
+			CommittedAt:   fileMetaData.CommittedAt,
+		},
+		DeletedAt: deletedAt,
+	}
+}
@@ -0,0 +1,113 @@
+package surfstore
+
+import (
+	context "context"
+	"testing"
+	"time"
+)
+
+func updateTestFile(t *testing.T, m *MetaStore, user string, filename string, hash string) {
+	version := int32(1)
+	if current, ok := m.FileMetaMap[namespacedName(user, filename)]; ok {
+		version = current.Version + 1
+	}
+	if v, err := m.UpdateFile(userContext(user), &FileMetaData{Filename: filename, Version: version, BlockHashList: []string{hash}}); err != nil || v.Version != version {
+		t.Fatalf("update of %v by %q: %v, %v", filename, user, v, err)
+	}
+}
+
+func TestUndeleteAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       string
+		path       string
+		permission Permission
+		undeleted  string
+	}{
+		{"owner", "alice", "docs/a.txt", Permission_NO_ACCESS, "docs/a.txt"},
+		{"owner's directory", "alice", "docs", Permission_NO_ACCESS, "docs/a.txt"},
+		{"own namespace only", "bob", "docs/a.txt", Permission_READ_WRITE, ""},
+		{"no share", "bob", "@shared/alice/docs/a.txt", Permission_NO_ACCESS, ""},
+		{"read share", "bob", "@shared/alice/docs/a.txt", Permission_READ, ""},
+		{"read write share", "bob", "@shared/alice/docs/a.txt", Permission_READ_WRITE, "@shared/alice/docs/a.txt"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMetaStore([]string{})
+			updateTestFile(t, m, "alice", "docs/a.txt", "h")
+			updateTestFile(t, m, "alice", "docs/a.txt", TOMBSTONE_HASHVALUE)
+			// a file of the same name another user deleted is not touched
+			updateTestFile(t, m, "carol", "docs/a.txt", "c")
+			updateTestFile(t, m, "carol", "docs/a.txt", TOMBSTONE_HASHVALUE)
+			if test.permission != Permission_NO_ACCESS {
+				if _, err := m.ShareFolder(userContext("alice"), &Share{Path: "docs", Grantee: "bob", Permission: test.permission}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			trash, err := m.Undelete(userContext(test.user), &TrashRequest{Path: test.path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			undeleted := ""
+			if len(trash.Files) == 1 {
+				undeleted = trash.Files[0].FileMetaData.Filename
+			}
+			if len(trash.Files) > 1 || undeleted != test.undeleted {
+				t.Errorf("undeleted %v, expected %q", trash.Files, test.undeleted)
+			}
+
+			alice := m.FileMetaMap[namespacedName("alice", "docs/a.txt")]
+			if restored := alice.BlockHashList[0] == "h"; restored != (test.undeleted != "") || (restored && alice.Version != 3) {
+				t.Errorf("alice's file at version %v with hashes %v", alice.Version, alice.BlockHashList)
+			}
+			if carol := m.FileMetaMap[namespacedName("carol", "docs/a.txt")]; !isTombstone(carol) {
+				t.Errorf("carol's file undeleted")
+			}
+		})
+	}
+}
+
+func TestTrashRetention(t *testing.T) {
+	m := NewMetaStore([]string{})
+	m.TrashRetention = time.Hour
+	retention := m.TrashRetention.Milliseconds()
+	update := func(filename string, version int32, hash string, at int64) {
+		entry := &LogEntry{FileMetaData: &FileMetaData{Filename: filename, Version: version, BlockHashList: []string{hash}, CommittedAt: at}}
+		if _, err := m.applyEntry(context.Background(), entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const start int64 = 1000000
+	update("a", 1, "h", start)
+	update("a", 2, TOMBSTONE_HASHVALUE, start+1000)
+	update("b", 1, "h", start)
+	update("b", 2, TOMBSTONE_HASHVALUE, start+2000)
+	// c is deleted, written again, and deleted again later
+	update("c", 1, "h", start)
+	update("c", 2, TOMBSTONE_HASHVALUE, start+1000)
+	update("c", 3, "h", start+1500)
+	update("c", 4, TOMBSTONE_HASHVALUE, start+3000)
+
+	tests := []struct {
+		now     int64
+		trashed []string
+	}{
+		{start + 1000 + retention - 1, []string{"a", "b", "c"}},
+		{start + 1000 + retention, []string{"b", "c"}},
+		{start + 2000 + retention, []string{"c"}},
+		{start + 3000 + retention, []string{}},
+	}
+	for i, test := range tests {
+		// any update purges what expired by the time it was committed
+		update("d", int32(i+1), "h", test.now)
+		if len(m.trash) != len(test.trashed) {
+			t.Errorf("at %v: %v files in the trash, expected %v", test.now, len(m.trash), test.trashed)
+		}
+		for _, filename := range test.trashed {
+			if _, ok := m.trash[filename]; !ok {
+				t.Errorf("at %v: %v not in the trash", test.now, filename)
+			}
+		}
+	}
+}
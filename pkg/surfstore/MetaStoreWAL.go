@@ -0,0 +1,252 @@
+package surfstore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const WAL_FILENAME string = "metastore.wal"
+const SNAPSHOT_FILENAME string = "metastore.snapshot"
+
+// Number of log entries appended between two snapshots
+const SNAPSHOT_INTERVAL int = 1000
+
+// Every record in the log is framed as
+//
+//	| length (4 bytes) | crc32 of payload (4 bytes) | payload (LogEntry) |
+//
+// so that a record torn by a crash can be detected and dropped on replay.
+const WAL_HEADER_SIZE int = 8
+const MAX_WAL_RECORD_SIZE uint32 = 64 << 20
+
+var errCorruptRecord = errors.New("corrupt write-ahead log record")
+
+// WriteAheadLog makes MetaStore updates durable. Entries are appended to
+// the log and fsynced before they are applied; every SNAPSHOT_INTERVAL
+// entries the whole state is written to a snapshot and the log is reset.
+type WriteAheadLog struct {
+	Dir           string
+	file          *os.File
+	size          int64
+	lastIndex     int64
+	sinceSnapshot int
+
+	// set once a failed append could not be undone; the log then refuses
+	// further appends, which replay would otherwise drop
+	failed error
+}
+
+// OpenWriteAheadLog opens (or creates) the log under dir. It returns the
+// latest snapshot, or nil if there is none, and the entries logged after it
+// which the caller must replay in order.
+func OpenWriteAheadLog(dir string) (*WriteAheadLog, *MetaStoreSnapshot, []*LogEntry, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, nil, err
+	}
+	w := &WriteAheadLog{Dir: dir}
+
+	snapshot, err := w.loadSnapshot()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if snapshot != nil {
+		w.lastIndex = snapshot.LastIndex
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, WAL_FILENAME), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	w.file = file
+
+	entries, validSize, err := readRecords(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	// drop a torn tail left behind by a crash mid-append
+	if err := file.Truncate(validSize); err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	w.size = validSize
+
+	// entries already covered by the snapshot are skipped; this happens if we
+	// crashed after writing a snapshot but before resetting the log
+	replay := []*LogEntry{}
+	for _, entry := range entries {
+		if entry.Index <= w.lastIndex {
+			continue
+		}
+		replay = append(replay, entry)
+		w.lastIndex = entry.Index
+	}
+	w.sinceSnapshot = len(replay)
+
+	return w, snapshot, replay, nil
+}
+
+// Append assigns the next index to entry and durably writes it to the log.
+// If that fails, whatever part of the record was written is dropped again.
+func (w *WriteAheadLog) Append(entry *LogEntry) error {
+	if w.failed != nil {
+		return w.failed
+	}
+	entry.Index = w.lastIndex + 1
+	record, err := encodeRecord(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(record); err != nil {
+		w.rollback()
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.rollback()
+		return err
+	}
+	w.size += int64(len(record))
+	w.lastIndex = entry.Index
+	w.sinceSnapshot++
+	return nil
+}
+
+// Cut the log back to the end of the last record appended in full, since
+// replay stops at a torn record and would lose every record after it.
+func (w *WriteAheadLog) rollback() {
+	if err := w.file.Truncate(w.size); err != nil {
+		w.failed = fmt.Errorf("write-ahead log left with a torn record: %v", err)
+	} else if _, err := w.file.Seek(w.size, io.SeekStart); err != nil {
+		w.failed = fmt.Errorf("write-ahead log left with a torn record: %v", err)
+	}
+	if w.failed != nil {
+		log.Println(w.failed)
+	}
+}
+
+// ShouldSnapshot reports whether enough entries have been appended since
+// the last snapshot that a new one should be taken.
+func (w *WriteAheadLog) ShouldSnapshot() bool {
+	return w.sinceSnapshot >= SNAPSHOT_INTERVAL
+}
+
+// Snapshot atomically replaces the snapshot file with snapshot, which must
+// reflect every entry appended so far, and then resets the log.
+func (w *WriteAheadLog) Snapshot(snapshot *MetaStoreSnapshot) error {
+	snapshot.LastIndex = w.lastIndex
+	data, err := proto.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(w.Dir, SNAPSHOT_FILENAME), data); err != nil {
+		return err
+	}
+
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	w.size = 0
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		return err
+	}
+	w.sinceSnapshot = 0
+	log.Println("metastore snapshot taken at index", w.lastIndex)
+	return nil
+}
+
+func (w *WriteAheadLog) Close() error {
+	return w.file.Close()
+}
+
+func (w *WriteAheadLog) loadSnapshot() (*MetaStoreSnapshot, error) {
+	data, err := ioutil.ReadFile(filepath.Join(w.Dir, SNAPSHOT_FILENAME))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	snapshot := &MetaStoreSnapshot{}
+	if err := proto.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// encodeRecord frames entry for appending to a log file.
+func encodeRecord(entry *LogEntry) ([]byte, error) {
+	payload, err := proto.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, WAL_HEADER_SIZE+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[WAL_HEADER_SIZE:], payload)
+	return record, nil
+}
+
+// readRecords decodes records from the start of file until the end or the
+// first torn or corrupt record. It returns the decoded entries and the size
+// of the valid prefix of the file.
+func readRecords(file *os.File) ([]*LogEntry, int64, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	reader := bufio.NewReader(file)
+	entries := []*LogEntry{}
+	var validSize int64
+	for {
+		entry, size, err := readRecord(reader)
+		if err == io.EOF || err == io.ErrUnexpectedEOF || err == errCorruptRecord {
+			if err != io.EOF {
+				log.Println("dropping torn log tail at offset", validSize)
+			}
+			return entries, validSize, nil
+		} else if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+		validSize += size
+	}
+}
+
+func readRecord(reader io.Reader) (*LogEntry, int64, error) {
+	header := make([]byte, WAL_HEADER_SIZE)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, 0, err
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	if length > MAX_WAL_RECORD_SIZE {
+		return nil, 0, errCorruptRecord
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, 0, errCorruptRecord
+	}
+	entry := &LogEntry{}
+	if err := proto.Unmarshal(payload, entry); err != nil {
+		return nil, 0, errCorruptRecord
+	}
+	return entry, int64(WAL_HEADER_SIZE) + int64(length), nil
+}
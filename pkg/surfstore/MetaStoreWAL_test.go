@@ -0,0 +1,149 @@
+package surfstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAheadLogTornTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		damage   func(data []byte) []byte
+		replayed int
+	}{
+		{"clean", func(data []byte) []byte { return data }, 3},
+		{"torn header", func(data []byte) []byte { return append(data, 0, 0, 0) }, 3},
+		{"torn payload", func(data []byte) []byte { return data[:len(data)-2] }, 2},
+		{"corrupt checksum", func(data []byte) []byte {
+			data[len(data)-1] ^= 0xff
+			return data
+		}, 2},
+		{"oversized length", func(data []byte) []byte { return append(data, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0) }, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			wal, snapshot, entries, err := OpenWriteAheadLog(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot != nil || len(entries) != 0 {
+				t.Fatalf("new log has a snapshot or %v entries", len(entries))
+			}
+			for _, filename := range []string{"a", "b", "c"} {
+				if err := wal.Append(&LogEntry{FileMetaData: &FileMetaData{Filename: filename, Version: 1}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			wal.Close()
+
+			path := filepath.Join(dir, WAL_FILENAME)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, test.damage(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			wal, _, entries, err = OpenWriteAheadLog(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != test.replayed {
+				t.Fatalf("replayed %v entries, expected %v", len(entries), test.replayed)
+			}
+			for i, entry := range entries {
+				if entry.Index != int64(i+1) {
+					t.Errorf("entry %v has index %v", i, entry.Index)
+				}
+			}
+
+			// appending after recovery continues where the valid prefix ended
+			if err := wal.Append(&LogEntry{FileMetaData: &FileMetaData{Filename: "d", Version: 1}}); err != nil {
+				t.Fatal(err)
+			}
+			wal.Close()
+			_, _, entries, err = OpenWriteAheadLog(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != test.replayed+1 || entries[test.replayed].FileMetaData.Filename != "d" {
+				t.Errorf("appended entry lost after recovery, replayed %v entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestWriteAheadLogSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	wal, _, _, err := OpenWriteAheadLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{"a", "b"} {
+		if err := wal.Append(&LogEntry{FileMetaData: &FileMetaData{Filename: filename, Version: 1}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wal.Snapshot(&MetaStoreSnapshot{ChangeSequence: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.Append(&LogEntry{FileMetaData: &FileMetaData{Filename: "c", Version: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+
+	_, snapshot, entries, err := OpenWriteAheadLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot == nil || snapshot.LastIndex != 2 || snapshot.ChangeSequence != 2 {
+		t.Fatalf("unexpected snapshot %v", snapshot)
+	}
+	if len(entries) != 1 || entries[0].Index != 3 {
+		t.Errorf("unexpected entries after the snapshot: %v", entries)
+	}
+}
+
+func TestWriteAheadLogFailedAppend(t *testing.T) {
+	dir := t.TempDir()
+	wal, _, _, err := OpenWriteAheadLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.Append(&LogEntry{FileMetaData: &FileMetaData{Filename: "a", Version: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	// a write that failed halfway through a record
+	record, err := encodeRecord(&LogEntry{Index: 2, FileMetaData: &FileMetaData{Filename: "lost", Version: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wal.file.Write(record[:len(record)/2]); err != nil {
+		t.Fatal(err)
+	}
+	wal.rollback()
+	if err := wal.Append(&LogEntry{FileMetaData: &FileMetaData{Filename: "b", Version: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	wal.Close()
+
+	wal, _, entries, err := OpenWriteAheadLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].FileMetaData.Filename != "a" || entries[1].FileMetaData.Filename != "b" || entries[1].Index != 2 {
+		t.Errorf("replayed %v, expected a and b", entries)
+	}
+
+	// a log that cannot be cut back refuses further appends
+	wal.file.Close()
+	if err := wal.Append(&LogEntry{FileMetaData: &FileMetaData{Filename: "c", Version: 1}}); err == nil || wal.failed == nil {
+		t.Fatalf("append to a closed log: %v, failed %v", err, wal.failed)
+	}
+	if err := wal.Append(&LogEntry{FileMetaData: &FileMetaData{Filename: "d", Version: 1}}); err != wal.failed {
+		t.Errorf("append after a failure: %v", err)
+	}
+}
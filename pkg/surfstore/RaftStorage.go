@@ -0,0 +1,191 @@
+package surfstore
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const RAFT_STATE_FILENAME string = "raft.state"
+const RAFT_LOG_FILENAME string = "raft.log"
+const RAFT_SNAPSHOT_FILENAME string = "raft.snapshot"
+
+// RaftStorage persists the state Raft needs to survive a restart: the
+// current term, the vote cast in it, the latest snapshot of the MetaStore
+// and the log entries after it. The log uses the same record framing as the
+// MetaStore write-ahead log.
+type RaftStorage struct {
+	Dir     string
+	logFile *os.File
+	logSize int64
+
+	// set once a failed append could not be undone; further appends are
+	// refused, as replay would drop them
+	failed error
+}
+
+// OpenRaftStorage opens (or creates) the Raft state under dir and returns it
+// together with the persisted term and vote, the latest snapshot, or nil if
+// there is none, and the log entries that follow the snapshot.
+func OpenRaftStorage(dir string) (*RaftStorage, *RaftState, *MetaStoreSnapshot, []*LogEntry, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	rs := &RaftStorage{Dir: dir}
+
+	state := &RaftState{CurrentTerm: 0, VotedFor: -1}
+	data, err := ioutil.ReadFile(filepath.Join(dir, RAFT_STATE_FILENAME))
+	if err == nil {
+		if err := proto.Unmarshal(data, state); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, nil, nil, err
+	}
+
+	var snapshot *MetaStoreSnapshot
+	data, err = ioutil.ReadFile(filepath.Join(dir, RAFT_SNAPSHOT_FILENAME))
+	if err == nil {
+		snapshot = &MetaStoreSnapshot{}
+		if err := proto.Unmarshal(data, snapshot); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, nil, nil, err
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(dir, RAFT_LOG_FILENAME), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	entries, validSize, err := readRecords(logFile)
+	if err != nil {
+		logFile.Close()
+		return nil, nil, nil, nil, err
+	}
+	if err := logFile.Truncate(validSize); err != nil {
+		logFile.Close()
+		return nil, nil, nil, nil, err
+	}
+	if _, err := logFile.Seek(validSize, io.SeekStart); err != nil {
+		logFile.Close()
+		return nil, nil, nil, nil, err
+	}
+	rs.logFile = logFile
+	rs.logSize = validSize
+
+	// entries the snapshot covers are left over from a crash between writing
+	// the snapshot and rewriting the log; entries that do not continue right
+	// after the snapshot are from before a snapshot installed from the leader
+	if snapshot != nil {
+		next := snapshot.LastIndex + 1
+		following := []*LogEntry{}
+		for _, entry := range entries {
+			if entry.Index == next {
+				following = append(following, entry)
+				next++
+			} else if entry.Index > next {
+				break
+			}
+		}
+		entries = following
+	}
+
+	return rs, state, snapshot, entries, nil
+}
+
+// SaveState durably records the current term and vote.
+func (rs *RaftStorage) SaveState(term int64, votedFor int64) error {
+	data, err := proto.Marshal(&RaftState{CurrentTerm: term, VotedFor: votedFor})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(rs.Dir, RAFT_STATE_FILENAME), data)
+}
+
+// Append durably adds entries to the end of the log.
+func (rs *RaftStorage) Append(entries []*LogEntry) error {
+	if rs.failed != nil {
+		return rs.failed
+	}
+	buf := []byte{}
+	for _, entry := range entries {
+		record, err := encodeRecord(entry)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, record...)
+	}
+	if _, err := rs.logFile.Write(buf); err != nil {
+		rs.rollback()
+		return err
+	}
+	if err := rs.logFile.Sync(); err != nil {
+		rs.rollback()
+		return err
+	}
+	rs.logSize += int64(len(buf))
+	return nil
+}
+
+// Cut the log back to the end of the last entry appended in full, since
+// replay stops at a torn record and would lose every entry after it.
+func (rs *RaftStorage) rollback() {
+	if err := rs.logFile.Truncate(rs.logSize); err != nil {
+		rs.failed = fmt.Errorf("raft log left with a torn record: %v", err)
+	} else if _, err := rs.logFile.Seek(rs.logSize, io.SeekStart); err != nil {
+		rs.failed = fmt.Errorf("raft log left with a torn record: %v", err)
+	}
+	if rs.failed != nil {
+		log.Println(rs.failed)
+	}
+}
+
+// Rewrite replaces the whole log with entries. It is used when a follower
+// has to discard a conflicting suffix of its log, and when the log is
+// compacted into a snapshot.
+func (rs *RaftStorage) Rewrite(entries []*LogEntry) error {
+	buf := []byte{}
+	for _, entry := range entries {
+		record, err := encodeRecord(entry)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, record...)
+	}
+	path := filepath.Join(rs.Dir, RAFT_LOG_FILENAME)
+	if err := writeFileAtomic(path, buf); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	rs.logFile.Close()
+	rs.logFile = logFile
+	rs.logSize = int64(len(buf))
+	rs.failed = nil
+	return nil
+}
+
+// SaveSnapshot durably replaces the snapshot, and then the log with the
+// entries that follow it.
+func (rs *RaftStorage) SaveSnapshot(snapshot *MetaStoreSnapshot, entries []*LogEntry) error {
+	data, err := proto.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(rs.Dir, RAFT_SNAPSHOT_FILENAME), data); err != nil {
+		return err
+	}
+	return rs.Rewrite(entries)
+}
+
+func (rs *RaftStorage) Close() error {
+	return rs.logFile.Close()
+}
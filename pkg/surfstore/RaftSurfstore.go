@@ -0,0 +1,978 @@
+package surfstore
+
+import (
+	context "context"
+	"log"
+	"math/rand"
+	sync "sync"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+const RAFT_HEARTBEAT_INTERVAL = 100 * time.Millisecond
+const RAFT_ELECTION_TIMEOUT_MIN = 500 * time.Millisecond
+const RAFT_ELECTION_TIMEOUT_MAX = 1000 * time.Millisecond
+const RAFT_RPC_TIMEOUT = 500 * time.Millisecond
+const RAFT_SNAPSHOT_TIMEOUT = 10 * time.Second
+const RAFT_TICK_INTERVAL = 10 * time.Millisecond
+
+// Maximum number of entries sent in one AppendEntries call
+const RAFT_MAX_BATCH int = 256
+
+// Largest message a Raft server accepts, so snapshots of a large MetaStore
+// can be installed
+const RAFT_MAX_MSG_SIZE = 256 << 20
+
+// Number of applied entries after which the log is compacted into a
+// snapshot of the MetaStore
+const RAFT_SNAPSHOT_INTERVAL int64 = int64(SNAPSHOT_INTERVAL)
+
+// Response header naming the address of the current leader, set when a
+// follower rejects a MetaStore call
+const LEADER_HEADER string = "surfstore-leader"
+
+var ERR_NOT_LEADER = status.Error(codes.FailedPrecondition, "Server is not the leader")
+
+type raftRole int
+
+const (
+	raftFollower raftRole = iota
+	raftCandidate
+	raftLeader
+)
+
+type raftResult struct {
+	msg proto.Message
+	err error
+}
+
+// A client request waiting for the entry at some index to be applied
+type raftWaiter struct {
+	term int64
+	ch   chan raftResult
+}
+
+// RaftSurfstore replicates a MetaStore across a cluster using Raft. Only
+// the leader serves MetaStore calls; updates are appended to the replicated
+// log and applied to the MetaStore once a majority has stored them, and
+// reads are answered once a majority confirmed the leader is current. Every
+// RAFT_SNAPSHOT_INTERVAL applied entries the log is compacted into a
+// snapshot of the MetaStore, which the leader sends to followers that lag
+// behind the start of its log.
+type RaftSurfstore struct {
+	id        int64
+	peers     []string
+	metaStore *MetaStore
+	storage   *RaftStorage
+
+	mtx sync.Mutex
+
+	// held while an entry or a snapshot is applied to the MetaStore, so the
+	// MetaStore always reflects exactly lastApplied; taken before mtx
+	applyMtx sync.Mutex
+
+	// persistent state; log[0] stands for the last entry compacted into
+	// snapshot, or is a placeholder at index 0 before the first snapshot
+	term     int64
+	votedFor int64
+	log      []*LogEntry
+	snapshot *MetaStoreSnapshot
+
+	// volatile state
+	role             raftRole
+	leaderId         int64
+	commitIndex      int64
+	lastApplied      int64
+	electionDeadline time.Time
+	nextHeartbeat    time.Time
+	rng              *rand.Rand
+	waiters          map[int64]*raftWaiter
+	applyCh          chan struct{}
+
+	// leader state
+	nextIndex   []int64
+	matchIndex  []int64
+	replicating []bool
+
+	conns []*grpc.ClientConn
+
+	UnimplementedMetaStoreServer
+	UnimplementedRaftSurfstoreServer
+}
+
+/* MetaStore service */
+
+func (s *RaftSurfstore) GetFileInfoMap(ctx context.Context, empty *emptypb.Empty) (*FileInfoMap, error) {
+	if err := s.confirmLeadership(ctx); err != nil {
+		return nil, err
+	}
+	return s.metaStore.GetFileInfoMap(ctx, empty)
+}
+
+func (s *RaftSurfstore) UpdateFile(ctx context.Context, fileMetaData *FileMetaData) (*Version, error) {
+	entry, err := s.metaStore.updateEntry(ctx, fileMetaData)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := s.propose(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*Version), nil
+}
+
+func (s *RaftSurfstore) GetBlockStoreMap(ctx context.Context, blockHashesIn *BlockHashes) (*BlockStoreMap, error) {
+	if err := s.confirmLeadership(ctx); err != nil {
+		return nil, err
+	}
+	return s.metaStore.GetBlockStoreMap(ctx, blockHashesIn)
+}
+
+func (s *RaftSurfstore) GetBlockStoreAddrs(ctx context.Context, empty *emptypb.Empty) (*BlockStoreAddrs, error) {
+	if err := s.confirmLeadership(ctx); err != nil {
+		return nil, err
+	}
+	return s.metaStore.GetBlockStoreAddrs(ctx, empty)
+}
+
+func (s *RaftSurfstore) AddBlockStore(ctx context.Context, blockStoreAddr *BlockStoreAddr) (*Success, error) {
+	msg, err := s.propose(ctx, &LogEntry{AddBlockStore: blockStoreAddr})
+	if err != nil {
+		return nil, err
+	}
+	succ := msg.(*Success)
+	if succ.Flag {
+		s.metaStore.requestRebalance()
+	}
+	return succ, nil
+}
+
+func (s *RaftSurfstore) RemoveBlockStore(ctx context.Context, blockStoreAddr *BlockStoreAddr) (*Success, error) {
+	msg, err := s.propose(ctx, &LogEntry{RemoveBlockStore: blockStoreAddr})
+	if err != nil {
+		return nil, err
+	}
+	succ := msg.(*Success)
+	if succ.Flag {
+		s.metaStore.requestRebalance()
+	}
+	return succ, nil
+}
+
+func (s *RaftSurfstore) isLeader() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.role == raftLeader
+}
+
+// Shares go through the log, recording the calling user as their owner, so
+// every server grants the same access.
+func (s *RaftSurfstore) ShareFolder(ctx context.Context, share *Share) (*Success, error) {
+	msg, err := s.propose(ctx, &LogEntry{Share: ownedShare(ctx, share)})
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*Success), nil
+}
+
+func (s *RaftSurfstore) UnshareFolder(ctx context.Context, share *Share) (*Success, error) {
+	msg, err := s.propose(ctx, &LogEntry{Unshare: ownedShare(ctx, share)})
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*Success), nil
+}
+
+func (s *RaftSurfstore) GetShares(ctx context.Context, empty *emptypb.Empty) (*Shares, error) {
+	if err := s.confirmLeadership(ctx); err != nil {
+		return nil, err
+	}
+	return s.metaStore.GetShares(ctx, empty)
+}
+
+func (s *RaftSurfstore) GetChangesSince(ctx context.Context, request *ChangesRequest) (*Changes, error) {
+	if err := s.confirmLeadership(ctx); err != nil {
+		return nil, err
+	}
+	return s.metaStore.GetChangesSince(ctx, request)
+}
+
+func (s *RaftSurfstore) GetFileVersions(ctx context.Context, request *VersionsRequest) (*FileVersions, error) {
+	if err := s.confirmLeadership(ctx); err != nil {
+		return nil, err
+	}
+	return s.metaStore.GetFileVersions(ctx, request)
+}
+
+func (s *RaftSurfstore) GetFileVersion(ctx context.Context, request *VersionRequest) (*FileMetaData, error) {
+	if err := s.confirmLeadership(ctx); err != nil {
+		return nil, err
+	}
+	return s.metaStore.GetFileVersion(ctx, request)
+}
+
+func (s *RaftSurfstore) GetTrash(ctx context.Context, request *TrashRequest) (*Trash, error) {
+	if err := s.confirmLeadership(ctx); err != nil {
+		return nil, err
+	}
+	return s.metaStore.GetTrash(ctx, request)
+}
+
+func (s *RaftSurfstore) Undelete(ctx context.Context, request *TrashRequest) (*Trash, error) {
+	msg, err := s.propose(ctx, &LogEntry{Undelete: undeleteEntry(ctx, request)})
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*Trash), nil
+}
+
+// Changes are streamed by the leader, which ends the stream when it loses
+// leadership so the client can resume with the new leader.
+func (s *RaftSurfstore) WatchChanges(request *WatchRequest, stream MetaStore_WatchChangesServer) error {
+	if err := s.confirmLeadership(stream.Context()); err != nil {
+		return err
+	}
+	return s.metaStore.WatchChanges(request, stream)
+}
+
+// Reject the call unless this server is the leader, pointing the client at
+// the leader if one is known.
+func (s *RaftSurfstore) checkLeaderLocked(ctx context.Context) error {
+	if s.role == raftLeader {
+		return nil
+	}
+	if s.leaderId >= 0 {
+		grpc.SetHeader(ctx, metadata.Pairs(LEADER_HEADER, s.peers[s.leaderId]))
+	}
+	return ERR_NOT_LEADER
+}
+
+// Confirm that this server is still the leader before it answers a read,
+// as in Raft's ReadIndex: a leader cut off from the cluster would answer
+// from a MetaStore that a newer leader has moved past. The commit index is
+// noted once an entry of the current term is committed, a round of
+// heartbeats checks that a majority still follows this leader, and the read
+// waits until the MetaStore has applied the noted index.
+func (s *RaftSurfstore) confirmLeadership(ctx context.Context) error {
+	// until an entry of its own term is committed, the leader may not know
+	// which entries earlier leaders committed
+	var term, readIndex int64
+	err := s.waitUntil(ctx, func() (bool, error) {
+		if err := s.checkLeaderLocked(ctx); err != nil {
+			return false, err
+		}
+		term, readIndex = s.term, s.commitIndex
+		return s.termAt(s.commitIndex) == s.term, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.heartbeatRound(term); err != nil {
+		return err
+	}
+	return s.waitUntil(ctx, func() (bool, error) {
+		return s.lastApplied >= readIndex, nil
+	})
+}
+
+// Send a heartbeat of term to every peer and return once a majority of the
+// cluster, counting this server, has accepted it.
+func (s *RaftSurfstore) heartbeatRound(term int64) error {
+	s.mtx.Lock()
+	n := len(s.peers)
+	// the last compacted entry is committed, so every follower that has it
+	// agrees with it
+	input := &AppendEntryInput{
+		Term:         term,
+		LeaderId:     s.id,
+		PrevLogIndex: s.firstIndex(),
+		PrevLogTerm:  s.termAt(s.firstIndex()),
+		LeaderCommit: s.commitIndex,
+	}
+	s.mtx.Unlock()
+
+	acks := make(chan bool, n)
+	for peer := 0; peer < n; peer++ {
+		if int64(peer) == s.id {
+			continue
+		}
+		go func(peer int) {
+			output, err := s.sendAppendEntries(peer, input)
+			if err != nil {
+				acks <- false
+				return
+			}
+			if output.Term > term {
+				s.mtx.Lock()
+				if output.Term > s.term {
+					s.becomeFollowerLocked(output.Term)
+				}
+				s.mtx.Unlock()
+			}
+			acks <- output.Term == term
+		}(peer)
+	}
+	acked, answered := 1, 1
+	for acked <= n/2 && answered < n {
+		if <-acks {
+			acked++
+		}
+		answered++
+	}
+	if acked <= n/2 {
+		return ERR_NOT_LEADER
+	}
+	return nil
+}
+
+// Wait until done, called with mtx held, reports true or fails
+func (s *RaftSurfstore) waitUntil(ctx context.Context, done func() (bool, error)) error {
+	for {
+		s.mtx.Lock()
+		ok, err := done()
+		s.mtx.Unlock()
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-time.After(RAFT_TICK_INTERVAL):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Append entry to the leader's log and wait until it has been committed and
+// applied, returning the MetaStore's result.
+func (s *RaftSurfstore) propose(ctx context.Context, entry *LogEntry) (proto.Message, error) {
+	s.mtx.Lock()
+	if err := s.checkLeaderLocked(ctx); err != nil {
+		s.mtx.Unlock()
+		return nil, err
+	}
+	entry.Term = s.term
+	entry.Index = s.lastIndex() + 1
+	if err := s.appendLocked([]*LogEntry{entry}); err != nil {
+		s.mtx.Unlock()
+		log.Println("Failed to persist log entry: ", err)
+		return nil, err
+	}
+	waiter := &raftWaiter{term: entry.Term, ch: make(chan raftResult, 1)}
+	s.waiters[entry.Index] = waiter
+	s.matchIndex[s.id] = entry.Index
+	s.advanceCommitLocked()
+	s.broadcastLocked()
+	s.mtx.Unlock()
+
+	select {
+	case res := <-waiter.ch:
+		return res.msg, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+/* RaftSurfstore service */
+
+func (s *RaftSurfstore) AppendEntries(ctx context.Context, input *AppendEntryInput) (*AppendEntryOutput, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	output := &AppendEntryOutput{ServerId: s.id, Term: s.term}
+	if input.Term < s.term {
+		return output, nil
+	}
+	if input.Term > s.term || s.role != raftFollower {
+		if err := s.becomeFollowerLocked(input.Term); err != nil {
+			return nil, err
+		}
+		output.Term = s.term
+	}
+	s.leaderId = input.LeaderId
+	s.resetElectionDeadlineLocked()
+
+	// our log must contain the entry preceding the new ones; a negative index
+	// only comes from a broken or hostile leader
+	prevIndex, entries := input.PrevLogIndex, input.Entries
+	if prevIndex < 0 {
+		return output, nil
+	}
+	if prevIndex > s.lastIndex() {
+		output.MatchedIndex = s.lastIndex()
+		return output, nil
+	}
+	// entries compacted into our snapshot are committed, so they match
+	if prevIndex < s.firstIndex() {
+		skip := min64(s.firstIndex()-prevIndex, int64(len(entries)))
+		prevIndex += skip
+		entries = entries[skip:]
+	}
+	if prevIndex >= s.firstIndex() && prevIndex == input.PrevLogIndex && s.termAt(prevIndex) != input.PrevLogTerm {
+		output.MatchedIndex = prevIndex - 1
+		return output, nil
+	}
+
+	// skip entries we already have, drop a conflicting suffix, append the rest
+	for i, entry := range entries {
+		index := prevIndex + 1 + int64(i)
+		if index <= s.lastIndex() && s.termAt(index) == entry.Term {
+			continue
+		}
+		if index <= s.lastIndex() {
+			if err := s.truncateLocked(index); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.appendLocked(entries[i:]); err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	matched := input.PrevLogIndex + int64(len(input.Entries))
+	if commit := min64(input.LeaderCommit, matched); commit > s.commitIndex {
+		s.commitIndex = commit
+		s.signalApply()
+	}
+
+	output.Success = true
+	output.MatchedIndex = matched
+	return output, nil
+}
+
+// Replace our state with the leader's snapshot, because the entries we
+// lack were compacted into it. Entries following the snapshot that we
+// already have are kept.
+func (s *RaftSurfstore) InstallSnapshot(ctx context.Context, input *InstallSnapshotInput) (*AppendEntryOutput, error) {
+	s.applyMtx.Lock()
+	defer s.applyMtx.Unlock()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	output := &AppendEntryOutput{ServerId: s.id, Term: s.term}
+	if input.Term < s.term || input.Snapshot == nil {
+		return output, nil
+	}
+	if input.Term > s.term || s.role != raftFollower {
+		if err := s.becomeFollowerLocked(input.Term); err != nil {
+			return nil, err
+		}
+		output.Term = s.term
+	}
+	s.leaderId = input.LeaderId
+	s.resetElectionDeadlineLocked()
+
+	snapshot := input.Snapshot
+	output.Success = true
+	output.MatchedIndex = snapshot.LastIndex
+	if snapshot.LastIndex <= s.lastApplied {
+		// we already applied everything it holds
+		return output, nil
+	}
+
+	remaining := []*LogEntry{}
+	if snapshot.LastIndex <= s.lastIndex() && s.termAt(snapshot.LastIndex) == snapshot.LastTerm {
+		remaining = append(remaining, s.log[snapshot.LastIndex-s.firstIndex()+1:]...)
+	}
+	if s.storage != nil {
+		if err := s.storage.SaveSnapshot(snapshot, remaining); err != nil {
+			log.Println("Failed to persist snapshot: ", err)
+			return nil, err
+		}
+	}
+	s.log = append([]*LogEntry{{Index: snapshot.LastIndex, Term: snapshot.LastTerm}}, remaining...)
+	s.snapshot = snapshot
+	s.metaStore.installSnapshot(snapshot)
+	s.lastApplied = snapshot.LastIndex
+	s.commitIndex = max64(s.commitIndex, snapshot.LastIndex)
+	// entries proposed while we led were overwritten or are in the snapshot,
+	// whose results are lost
+	for index, waiter := range s.waiters {
+		if index <= snapshot.LastIndex {
+			waiter.ch <- raftResult{err: ERR_NOT_LEADER}
+			delete(s.waiters, index)
+		}
+	}
+	log.Printf("raft server %v installed snapshot at index %v\n", s.id, snapshot.LastIndex)
+	return output, nil
+}
+
+func (s *RaftSurfstore) RequestVote(ctx context.Context, input *RequestVoteInput) (*RequestVoteOutput, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if input.Term > s.term {
+		if err := s.becomeFollowerLocked(input.Term); err != nil {
+			return nil, err
+		}
+	}
+	output := &RequestVoteOutput{Term: s.term}
+	if input.Term < s.term || (s.votedFor != -1 && s.votedFor != input.CandidateId) {
+		return output, nil
+	}
+
+	// only vote for candidates whose log is at least as up to date as ours
+	lastTerm := s.termAt(s.lastIndex())
+	if input.LastLogTerm < lastTerm || (input.LastLogTerm == lastTerm && input.LastLogIndex < s.lastIndex()) {
+		return output, nil
+	}
+
+	s.votedFor = input.CandidateId
+	if err := s.saveStateLocked(); err != nil {
+		return nil, err
+	}
+	s.resetElectionDeadlineLocked()
+	output.VoteGranted = true
+	return output, nil
+}
+
+/* Elections and replication */
+
+// Start the election timer, heartbeats and the apply loop.
+func (s *RaftSurfstore) Start() {
+	go s.tick()
+	go s.applyCommitted()
+}
+
+func (s *RaftSurfstore) tick() {
+	for range time.Tick(RAFT_TICK_INTERVAL) {
+		s.mtx.Lock()
+		now := time.Now()
+		if s.role == raftLeader {
+			if now.After(s.nextHeartbeat) {
+				s.broadcastLocked()
+			}
+		} else if now.After(s.electionDeadline) {
+			s.startElectionLocked()
+		}
+		s.mtx.Unlock()
+	}
+}
+
+func (s *RaftSurfstore) startElectionLocked() {
+	s.role = raftCandidate
+	s.term++
+	s.votedFor = s.id
+	s.leaderId = -1
+	if err := s.saveStateLocked(); err != nil {
+		log.Println("Failed to persist raft state: ", err)
+		return
+	}
+	s.resetElectionDeadlineLocked()
+	log.Printf("raft server %v starting election for term %v\n", s.id, s.term)
+
+	term := s.term
+	input := &RequestVoteInput{
+		Term:         term,
+		CandidateId:  s.id,
+		LastLogIndex: s.lastIndex(),
+		LastLogTerm:  s.termAt(s.lastIndex()),
+	}
+	votes := 1
+	if votes > len(s.peers)/2 {
+		s.becomeLeaderLocked()
+		return
+	}
+	for peer := range s.peers {
+		if int64(peer) == s.id {
+			continue
+		}
+		go func(peer int) {
+			client, err := s.peerClient(peer)
+			if err != nil {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), RAFT_RPC_TIMEOUT)
+			defer cancel()
+			output, err := client.RequestVote(ctx, input)
+			if err != nil {
+				return
+			}
+
+			s.mtx.Lock()
+			defer s.mtx.Unlock()
+			if output.Term > s.term {
+				s.becomeFollowerLocked(output.Term)
+				return
+			}
+			if s.role != raftCandidate || s.term != term || !output.VoteGranted {
+				return
+			}
+			votes++
+			if votes > len(s.peers)/2 {
+				s.becomeLeaderLocked()
+			}
+		}(peer)
+	}
+}
+
+func (s *RaftSurfstore) becomeLeaderLocked() {
+	log.Printf("raft server %v is leader for term %v\n", s.id, s.term)
+	s.role = raftLeader
+	s.leaderId = s.id
+	for peer := range s.peers {
+		s.nextIndex[peer] = s.lastIndex() + 1
+		s.matchIndex[peer] = 0
+	}
+	// commit a no-op so entries from earlier terms can be committed
+	noop := &LogEntry{Term: s.term, Index: s.lastIndex() + 1}
+	if noop.Index == 1 {
+		// the first leader names the epoch all servers number changes in,
+		// so change cursors stay valid when the leader changes
+		noop.Epoch = newEpoch()
+	}
+	if err := s.appendLocked([]*LogEntry{noop}); err != nil {
+		log.Println("Failed to persist log entry: ", err)
+	}
+	s.matchIndex[s.id] = s.lastIndex()
+	s.advanceCommitLocked()
+	s.broadcastLocked()
+	// a migration the previous leader started may not have finished
+	s.metaStore.requestRebalance()
+}
+
+func (s *RaftSurfstore) becomeFollowerLocked(term int64) error {
+	if term > s.term {
+		s.term = term
+		s.votedFor = -1
+		if err := s.saveStateLocked(); err != nil {
+			log.Println("Failed to persist raft state: ", err)
+			return err
+		}
+	}
+	if s.role == raftLeader {
+		log.Printf("raft server %v stepping down in term %v\n", s.id, s.term)
+	}
+	s.role = raftFollower
+	return nil
+}
+
+// Send AppendEntries to every follower that has no call outstanding. With
+// nothing new to send this is a heartbeat.
+func (s *RaftSurfstore) broadcastLocked() {
+	s.nextHeartbeat = time.Now().Add(RAFT_HEARTBEAT_INTERVAL)
+	for peer := range s.peers {
+		if int64(peer) == s.id || s.replicating[peer] {
+			continue
+		}
+		s.replicating[peer] = true
+		go s.replicate(peer)
+	}
+}
+
+func (s *RaftSurfstore) replicate(peer int) {
+	for {
+		s.mtx.Lock()
+		if s.role != raftLeader {
+			s.replicating[peer] = false
+			s.mtx.Unlock()
+			return
+		}
+		term := s.term
+		var send func() (*AppendEntryOutput, error)
+		prevIndex := s.nextIndex[peer] - 1
+		if prevIndex < s.firstIndex() {
+			// the entries the follower lacks were compacted into the snapshot
+			input := &InstallSnapshotInput{Term: term, LeaderId: s.id, Snapshot: s.snapshot}
+			send = func() (*AppendEntryOutput, error) { return s.sendInstallSnapshot(peer, input) }
+		} else {
+			end := min64(s.lastIndex()+1, s.nextIndex[peer]+int64(RAFT_MAX_BATCH))
+			input := &AppendEntryInput{
+				Term:         term,
+				LeaderId:     s.id,
+				PrevLogIndex: prevIndex,
+				PrevLogTerm:  s.termAt(prevIndex),
+				// copied, since the log may be truncated or compacted once
+				// the lock is released
+				Entries:      append([]*LogEntry{}, s.log[prevIndex+1-s.firstIndex():end-s.firstIndex()]...),
+				LeaderCommit: s.commitIndex,
+			}
+			send = func() (*AppendEntryOutput, error) { return s.sendAppendEntries(peer, input) }
+		}
+		s.mtx.Unlock()
+
+		output, err := send()
+
+		s.mtx.Lock()
+		if err != nil || s.role != raftLeader || s.term != term {
+			s.replicating[peer] = false
+			s.mtx.Unlock()
+			return
+		}
+		if output.Term > s.term {
+			s.becomeFollowerLocked(output.Term)
+			s.replicating[peer] = false
+			s.mtx.Unlock()
+			return
+		}
+		if output.Success {
+			s.matchIndex[peer] = max64(s.matchIndex[peer], output.MatchedIndex)
+			s.nextIndex[peer] = s.matchIndex[peer] + 1
+			s.advanceCommitLocked()
+		} else {
+			// back off to just past what the follower says it has
+			s.nextIndex[peer] = max64(1, min64(s.nextIndex[peer]-1, output.MatchedIndex+1))
+		}
+		done := output.Success && s.nextIndex[peer] > s.lastIndex()
+		if done {
+			s.replicating[peer] = false
+		}
+		s.mtx.Unlock()
+		if done {
+			return
+		}
+	}
+}
+
+func (s *RaftSurfstore) sendAppendEntries(peer int, input *AppendEntryInput) (*AppendEntryOutput, error) {
+	client, err := s.peerClient(peer)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), RAFT_RPC_TIMEOUT)
+	defer cancel()
+	return client.AppendEntries(ctx, input)
+}
+
+func (s *RaftSurfstore) sendInstallSnapshot(peer int, input *InstallSnapshotInput) (*AppendEntryOutput, error) {
+	client, err := s.peerClient(peer)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), RAFT_SNAPSHOT_TIMEOUT)
+	defer cancel()
+	return client.InstallSnapshot(ctx, input)
+}
+
+// Advance the commit index to the highest entry of the current term that a
+// majority of servers have stored.
+func (s *RaftSurfstore) advanceCommitLocked() {
+	for index := s.lastIndex(); index > s.commitIndex; index-- {
+		if s.termAt(index) != s.term {
+			break
+		}
+		count := 0
+		for peer := range s.peers {
+			if s.matchIndex[peer] >= index {
+				count++
+			}
+		}
+		if count > len(s.peers)/2 {
+			s.commitIndex = index
+			s.signalApply()
+			return
+		}
+	}
+}
+
+func (s *RaftSurfstore) signalApply() {
+	select {
+	case s.applyCh <- struct{}{}:
+	default:
+	}
+}
+
+// Apply committed entries to the MetaStore in log order and hand results
+// to waiting clients, compacting the log every RAFT_SNAPSHOT_INTERVAL
+// entries.
+func (s *RaftSurfstore) applyCommitted() {
+	for range s.applyCh {
+		for s.applyNext() {
+		}
+	}
+}
+
+// Apply the next committed entry, if there is one
+func (s *RaftSurfstore) applyNext() bool {
+	s.applyMtx.Lock()
+	defer s.applyMtx.Unlock()
+	s.mtx.Lock()
+	if s.lastApplied >= s.commitIndex {
+		s.mtx.Unlock()
+		return false
+	}
+	s.lastApplied++
+	index := s.lastApplied
+	entry := s.entryAt(index)
+	waiter, ok := s.waiters[index]
+	delete(s.waiters, index)
+	compact := index-s.firstIndex() >= RAFT_SNAPSHOT_INTERVAL
+	s.mtx.Unlock()
+
+	msg, err := s.metaStore.applyEntry(context.Background(), entry)
+	if ok {
+		if waiter.term == entry.Term {
+			waiter.ch <- raftResult{msg: msg, err: err}
+		} else {
+			// the proposed entry was overwritten by another leader
+			waiter.ch <- raftResult{err: ERR_NOT_LEADER}
+		}
+	}
+
+	if compact {
+		snapshot := s.metaStore.exportSnapshot()
+		snapshot.LastIndex = index
+		snapshot.LastTerm = entry.Term
+		s.mtx.Lock()
+		s.compactLocked(snapshot)
+		s.mtx.Unlock()
+	}
+	return true
+}
+
+// Drop the entries a snapshot of the MetaStore covers from the log. A
+// failed compaction only leaves the log longer.
+func (s *RaftSurfstore) compactLocked(snapshot *MetaStoreSnapshot) {
+	if snapshot.LastIndex <= s.firstIndex() {
+		return
+	}
+	remaining := append([]*LogEntry{}, s.log[snapshot.LastIndex-s.firstIndex()+1:]...)
+	if s.storage != nil {
+		if err := s.storage.SaveSnapshot(snapshot, remaining); err != nil {
+			log.Println("Failed to persist snapshot: ", err)
+			return
+		}
+	}
+	s.log = append([]*LogEntry{{Index: snapshot.LastIndex, Term: snapshot.LastTerm}}, remaining...)
+	s.snapshot = snapshot
+	log.Printf("raft server %v compacted its log up to index %v\n", s.id, snapshot.LastIndex)
+}
+
+/* Log and state helpers */
+
+// Index of log[0], the last entry compacted into the snapshot
+func (s *RaftSurfstore) firstIndex() int64 {
+	return s.log[0].Index
+}
+
+func (s *RaftSurfstore) lastIndex() int64 {
+	return s.firstIndex() + int64(len(s.log)-1)
+}
+
+// Entry at index, which must lie between firstIndex and lastIndex
+func (s *RaftSurfstore) entryAt(index int64) *LogEntry {
+	return s.log[index-s.firstIndex()]
+}
+
+func (s *RaftSurfstore) termAt(index int64) int64 {
+	return s.entryAt(index).Term
+}
+
+func (s *RaftSurfstore) appendLocked(entries []*LogEntry) error {
+	if s.storage != nil {
+		if err := s.storage.Append(entries); err != nil {
+			return err
+		}
+	}
+	s.log = append(s.log, entries...)
+	return nil
+}
+
+// Drop the entries at index and after.
+func (s *RaftSurfstore) truncateLocked(index int64) error {
+	if s.storage != nil {
+		if err := s.storage.Rewrite(s.log[1 : index-s.firstIndex()]); err != nil {
+			return err
+		}
+	}
+	s.log = s.log[:index-s.firstIndex()]
+	return nil
+}
+
+func (s *RaftSurfstore) saveStateLocked() error {
+	if s.storage == nil {
+		return nil
+	}
+	return s.storage.SaveState(s.term, s.votedFor)
+}
+
+func (s *RaftSurfstore) resetElectionDeadlineLocked() {
+	spread := int64(RAFT_ELECTION_TIMEOUT_MAX - RAFT_ELECTION_TIMEOUT_MIN)
+	timeout := RAFT_ELECTION_TIMEOUT_MIN + time.Duration(s.rng.Int63n(spread))
+	s.electionDeadline = time.Now().Add(timeout)
+}
+
+func (s *RaftSurfstore) peerClient(peer int) (RaftSurfstoreClient, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.conns[peer] == nil {
+		conn, err := grpc.Dial(s.peers[peer], dialOptions(s.metaStore.DialOption, s.metaStore.Token)...)
+		if err != nil {
+			return nil, err
+		}
+		s.conns[peer] = conn
+	}
+	return NewRaftSurfstoreClient(s.conns[peer]), nil
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// This line guarantees all method for RaftSurfstore are implemented
+var _ MetaStoreInterface = new(RaftSurfstore)
+
+// NewRaftSurfstore creates server id of the cluster whose addresses are
+// peers, replicating metaStore. If dataDir is not empty the Raft term, vote,
+// snapshot and log are persisted there, and metaStore starts out with the
+// persisted snapshot.
+func NewRaftSurfstore(id int64, peers []string, metaStore *MetaStore, dataDir string) (*RaftSurfstore, error) {
+	s := &RaftSurfstore{
+		id:          id,
+		peers:       peers,
+		metaStore:   metaStore,
+		term:        0,
+		votedFor:    -1,
+		log:         []*LogEntry{{Index: 0, Term: 0}},
+		role:        raftFollower,
+		leaderId:    -1,
+		waiters:     map[int64]*raftWaiter{},
+		applyCh:     make(chan struct{}, 1),
+		nextIndex:   make([]int64, len(peers)),
+		matchIndex:  make([]int64, len(peers)),
+		replicating: make([]bool, len(peers)),
+		conns:       make([]*grpc.ClientConn, len(peers)),
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano() + id)),
+	}
+
+	if dataDir != "" {
+		storage, state, snapshot, entries, err := OpenRaftStorage(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		s.storage = storage
+		s.term = state.CurrentTerm
+		s.votedFor = state.VotedFor
+		if snapshot != nil {
+			s.log = []*LogEntry{{Index: snapshot.LastIndex, Term: snapshot.LastTerm}}
+			s.snapshot = snapshot
+			metaStore.installSnapshot(snapshot)
+			s.commitIndex = snapshot.LastIndex
+			s.lastApplied = snapshot.LastIndex
+		}
+		s.log = append(s.log, entries...)
+		log.Printf("raft server %v recovered term %v with a snapshot at index %v and %v log entries\n", id, s.term, s.firstIndex(), len(entries))
+	}
+
+	// only the leader moves and collects blocks
+	metaStore.isLeader = s.isLeader
+
+	s.resetElectionDeadlineLocked()
+	return s, nil
+}
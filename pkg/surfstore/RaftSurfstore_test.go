@@ -0,0 +1,293 @@
+package surfstore
+
+import (
+	context "context"
+	"net"
+	"testing"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// A server of a cluster of n that is never started, so tests drive it
+// through its RPCs alone
+func newTestRaftServer(t *testing.T, n int) *RaftSurfstore {
+	peers := make([]string, n)
+	for i := range peers {
+		peers[i] = "127.0.0.1:0"
+	}
+	s, err := NewRaftSurfstore(0, peers, NewMetaStore([]string{}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func logTerms(s *RaftSurfstore) []int64 {
+	terms := []int64{}
+	for index := s.firstIndex() + 1; index <= s.lastIndex(); index++ {
+		terms = append(terms, s.termAt(index))
+	}
+	return terms
+}
+
+func equalTerms(a []int64, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testEntries(first int64, terms ...int64) []*LogEntry {
+	entries := []*LogEntry{}
+	for i, term := range terms {
+		entries = append(entries, &LogEntry{Index: first + int64(i), Term: term})
+	}
+	return entries
+}
+
+func TestRaftAppendEntries(t *testing.T) {
+	tests := []struct {
+		name      string
+		log       []int64
+		prevIndex int64
+		prevTerm  int64
+		entries   []int64
+		success   bool
+		matched   int64
+		result    []int64
+	}{
+		{"append", []int64{1, 1}, 2, 1, []int64{2}, true, 3, []int64{1, 1, 2}},
+		{"conflicting suffix", []int64{1, 1, 1}, 1, 1, []int64{2}, true, 2, []int64{1, 2}},
+		{"entries already held", []int64{1, 1, 2}, 1, 1, []int64{1}, true, 2, []int64{1, 1, 2}},
+		{"prev term mismatch", []int64{1, 1}, 2, 2, []int64{2}, false, 1, []int64{1, 1}},
+		{"prev index missing", []int64{1}, 3, 1, []int64{2}, false, 1, []int64{1}},
+		{"negative prev index", []int64{1}, -1, 0, []int64{2}, false, 0, []int64{1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestRaftServer(t, 3)
+			s.term = 2
+			s.log = append(s.log, testEntries(1, test.log...)...)
+			output, err := s.AppendEntries(context.Background(), &AppendEntryInput{
+				Term:         2,
+				LeaderId:     1,
+				PrevLogIndex: test.prevIndex,
+				PrevLogTerm:  test.prevTerm,
+				Entries:      testEntries(test.prevIndex+1, test.entries...),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output.Success != test.success || output.MatchedIndex != test.matched {
+				t.Errorf("success %v, matched %v; expected %v, %v", output.Success, output.MatchedIndex, test.success, test.matched)
+			}
+			if terms := logTerms(s); !equalTerms(terms, test.result) {
+				t.Errorf("log terms %v, expected %v", terms, test.result)
+			}
+		})
+	}
+}
+
+func TestRaftAppendEntriesBelowSnapshot(t *testing.T) {
+	s := newTestRaftServer(t, 3)
+	s.term = 1
+	// entries up to 5 were compacted into a snapshot
+	s.log = append([]*LogEntry{{Index: 5, Term: 1}}, testEntries(6, 1)...)
+	s.commitIndex, s.lastApplied = 5, 5
+
+	output, err := s.AppendEntries(context.Background(), &AppendEntryInput{
+		Term:         1,
+		LeaderId:     1,
+		PrevLogIndex: 3,
+		PrevLogTerm:  1,
+		Entries:      testEntries(4, 1, 1, 1, 1),
+		LeaderCommit: 7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !output.Success || output.MatchedIndex != 7 {
+		t.Errorf("success %v, matched %v; expected true, 7", output.Success, output.MatchedIndex)
+	}
+	if s.firstIndex() != 5 || s.lastIndex() != 7 || s.commitIndex != 7 {
+		t.Errorf("log from %v to %v committed to %v; expected 5 to 7 committed to 7", s.firstIndex(), s.lastIndex(), s.commitIndex)
+	}
+
+	// the entries the snapshot covers are taken as matching, whatever the
+	// term the leader claims for the one before
+	output, err = s.AppendEntries(context.Background(), &AppendEntryInput{Term: 1, LeaderId: 1, PrevLogIndex: 2, PrevLogTerm: 9})
+	if err != nil || !output.Success || s.lastIndex() != 7 {
+		t.Errorf("heartbeat below the snapshot: %v, %v, log to %v", output, err, s.lastIndex())
+	}
+}
+
+func TestRaftInstallSnapshot(t *testing.T) {
+	snapshot := func(lastIndex int64, filename string) *MetaStoreSnapshot {
+		return &MetaStoreSnapshot{
+			LastIndex:   lastIndex,
+			LastTerm:    1,
+			FileMetaMap: map[string]*FileMetaData{filename: {Filename: filename, Version: 1, BlockHashList: []string{"h"}}},
+			Epoch:       "e",
+		}
+	}
+
+	s := newTestRaftServer(t, 3)
+	s.term = 3
+	s.log = append(s.log, testEntries(1, 1, 1)...)
+	output, err := s.InstallSnapshot(context.Background(), &InstallSnapshotInput{Term: 2, LeaderId: 1, Snapshot: snapshot(4, "stale")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Success || output.Term != 3 {
+		t.Errorf("stale snapshot: success %v, term %v", output.Success, output.Term)
+	}
+	if _, ok := s.metaStore.FileMetaMap["stale"]; ok || s.lastIndex() != 2 || s.lastApplied != 0 {
+		t.Errorf("stale snapshot installed")
+	}
+
+	output, err = s.InstallSnapshot(context.Background(), &InstallSnapshotInput{Term: 3, LeaderId: 1, Snapshot: snapshot(4, "current")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !output.Success || output.MatchedIndex != 4 {
+		t.Errorf("current snapshot: success %v, matched %v", output.Success, output.MatchedIndex)
+	}
+	if _, ok := s.metaStore.FileMetaMap["current"]; !ok || s.firstIndex() != 4 || s.lastIndex() != 4 || s.lastApplied != 4 || s.metaStore.epoch != "e" {
+		t.Errorf("current snapshot not installed: log from %v to %v, applied %v", s.firstIndex(), s.lastIndex(), s.lastApplied)
+	}
+}
+
+func TestRaftLeaderCommitsNoop(t *testing.T) {
+	s := newTestRaftServer(t, 1)
+	// entries of an earlier term are only committed along with one of the
+	// leader's own
+	s.term = 1
+	s.log = append(s.log, &LogEntry{Index: 1, Term: 1, FileMetaData: &FileMetaData{Filename: "f", Version: 1, BlockHashList: []string{"h"}}})
+
+	s.mtx.Lock()
+	s.startElectionLocked()
+	s.mtx.Unlock()
+	if !s.isLeader() || s.term != 2 {
+		t.Fatalf("single server not leader of term 2")
+	}
+	if s.lastIndex() != 2 || s.termAt(2) != 2 || s.commitIndex != 2 {
+		t.Errorf("log to %v, last term %v, committed to %v; expected a no-op of term 2 committed", s.lastIndex(), s.termAt(s.lastIndex()), s.commitIndex)
+	}
+	for s.applyNext() {
+	}
+	if _, ok := s.metaStore.FileMetaMap["f"]; !ok || s.lastApplied != 2 {
+		t.Errorf("entry of the earlier term not applied, applied to %v", s.lastApplied)
+	}
+}
+
+func TestRaftEpochReplicated(t *testing.T) {
+	leader := newTestRaftServer(t, 1)
+	leader.mtx.Lock()
+	leader.startElectionLocked()
+	leader.mtx.Unlock()
+	for leader.applyNext() {
+	}
+	epoch := leader.entryAt(1).Epoch
+	if epoch == "" || leader.metaStore.epoch != epoch {
+		t.Fatalf("first entry names epoch %q, leader uses %q", epoch, leader.metaStore.epoch)
+	}
+
+	follower := newTestRaftServer(t, 3)
+	if follower.metaStore.epoch == epoch {
+		t.Fatalf("new server already has the cluster's epoch")
+	}
+	output, err := follower.AppendEntries(context.Background(), &AppendEntryInput{
+		Term:         1,
+		LeaderId:     1,
+		Entries:      []*LogEntry{leader.entryAt(1)},
+		LeaderCommit: 1,
+	})
+	if err != nil || !output.Success {
+		t.Fatalf("append: %v, %v", output, err)
+	}
+	for follower.applyNext() {
+	}
+	if follower.metaStore.epoch != epoch {
+		t.Errorf("follower uses epoch %q, expected %q", follower.metaStore.epoch, epoch)
+	}
+
+	// a later leader keeps it
+	follower.mtx.Lock()
+	follower.becomeLeaderLocked()
+	follower.mtx.Unlock()
+	if follower.entryAt(2).Epoch != "" {
+		t.Errorf("later leader names a new epoch")
+	}
+}
+
+// A Raft peer answering every AppendEntries with its term
+type stubRaftPeer struct {
+	term int64
+	UnimplementedRaftSurfstoreServer
+}
+
+func (p *stubRaftPeer) AppendEntries(ctx context.Context, input *AppendEntryInput) (*AppendEntryOutput, error) {
+	return &AppendEntryOutput{Term: p.term}, nil
+}
+
+func startStubRaftPeer(t *testing.T, term int64) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	RegisterRaftSurfstoreServer(server, &stubRaftPeer{term: term})
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+	return l.Addr().String()
+}
+
+func TestRaftReadsConfirmLeadership(t *testing.T) {
+	tests := []struct {
+		name   string
+		peers  func(t *testing.T) []string
+		leader bool
+		term   int64
+	}{
+		{"majority follows", func(t *testing.T) []string { return []string{startStubRaftPeer(t, 1), "127.0.0.1:0"} }, true, 1},
+		{"cut off", func(t *testing.T) []string { return []string{"127.0.0.1:0", "127.0.0.1:0"} }, false, 1},
+		{"newer leader", func(t *testing.T) []string { return []string{startStubRaftPeer(t, 2), "127.0.0.1:0"} }, false, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			peers := append([]string{"127.0.0.1:0"}, test.peers(t)...)
+			s, err := NewRaftSurfstore(0, peers, NewMetaStore([]string{}), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a leader of term 1 whose no-op was committed
+			s.term, s.role, s.leaderId = 1, raftLeader, 0
+			s.log = append(s.log, &LogEntry{Index: 1, Term: 1})
+			s.commitIndex = 1
+			for s.applyNext() {
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			_, err = s.GetFileInfoMap(ctx, &emptypb.Empty{})
+			if test.leader && err != nil {
+				t.Errorf("read: %v", err)
+			}
+			if !test.leader && status.Code(err) != codes.FailedPrecondition {
+				t.Errorf("read: %v, expected the server not to be leader", err)
+			}
+			if s.term != test.term || (test.term > 1 && s.isLeader()) {
+				t.Errorf("leader now in term %v, leader %v; expected term %v", s.term, s.isLeader(), test.term)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package surfstore
+
+import (
+	context "context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// Deadline of unary calls no other deadline is configured for
+const DEFAULT_RPC_TIMEOUT = time.Second
+
+// Deadline of the calls the MetaStore makes to block servers in the
+// background
+const MAINTENANCE_RPC_TIMEOUT = time.Minute
+
+// Idempotent calls are tried this many times in all, waiting a growing,
+// randomized backoff between attempts
+const DEFAULT_RETRY_ATTEMPTS int = 4
+const RETRY_INITIAL_BACKOFF = 100 * time.Millisecond
+const RETRY_MAX_BACKOFF = 2 * time.Second
+const RETRY_BACKOFF_MULTIPLIER float64 = 2
+
+// Separates the entries of a timeout list, and names from durations
+const TIMEOUT_DELIMITER string = ","
+const TIMEOUT_ASSIGN string = "="
+
+// A TransientError is a call that failed because a server was unreachable,
+// overloaded or too slow. Trying again later may succeed.
+type TransientError struct {
+	Method string
+	Addr   string
+	Err    error
+}
+
+func (e *TransientError) Error() string {
+	return fmt.Sprintf("%v to %v failed, may succeed on retry: %v", e.Method, e.Addr, e.Err)
+}
+
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// Keeps the gRPC status visible to status.Code and status.FromError
+func (e *TransientError) GRPCStatus() *status.Status { return status.Convert(e.Err) }
+
+// A PermanentError is a call a server refused, such as one lacking
+// permission or naming a block that does not exist. Trying again fails the
+// same way.
+type PermanentError struct {
+	Method string
+	Addr   string
+	Err    error
+}
+
+func (e *PermanentError) Error() string {
+	return fmt.Sprintf("%v to %v failed: %v", e.Method, e.Addr, e.Err)
+}
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+func (e *PermanentError) GRPCStatus() *status.Status { return status.Convert(e.Err) }
+
+// Whether err, returned by an RPCClient call, may go away on retry
+func IsTransient(err error) bool {
+	var transient *TransientError
+	return errors.As(err, &transient)
+}
+
+// Classify the error of a call as a TransientError or a PermanentError
+func newRPCError(method string, addr string, err error) error {
+	var transient *TransientError
+	var permanent *PermanentError
+	if err == nil || errors.As(err, &transient) || errors.As(err, &permanent) {
+		return err
+	}
+	if isTransientError(err) {
+		return &TransientError{Method: method, Addr: addr, Err: err}
+	}
+	return &PermanentError{Method: method, Addr: addr, Err: err}
+}
+
+// Errors without a gRPC status come from the connection or a broken stream,
+// and are transient unless the client itself was closed.
+func isTransientError(err error) bool {
+	var transient *TransientError
+	var permanent *PermanentError
+	if errors.As(err, &transient) {
+		return true
+	} else if errors.As(err, &permanent) || errors.Is(err, errClientClosed) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return true
+	}
+	switch s.Code() {
+	// FailedPrecondition is what Raft followers answer while there is no
+	// leader to forward to. ResourceExhausted is left out, as gRPC reports
+	// messages over the size limit with it, and they never get smaller.
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+// RetryPolicy says how often a failed idempotent call is tried again, and
+// how long to wait in between: InitialBackoff, growing by Multiplier with
+// every attempt up to MaxBackoff, of which a random half is waited.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    DEFAULT_RETRY_ATTEMPTS,
+		InitialBackoff: RETRY_INITIAL_BACKOFF,
+		MaxBackoff:     RETRY_MAX_BACKOFF,
+		Multiplier:     RETRY_BACKOFF_MULTIPLIER,
+	}
+}
+
+// Backoff before the given retry, counting from 1
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return time.Duration(backoff)
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// Run call until it succeeds, fails with a permanent error or the attempts
+// are used up. A nil policy runs it once.
+func (p *RetryPolicy) run(call func() error) error {
+	err := call()
+	if p == nil {
+		return err
+	}
+	for retry := 1; retry < p.MaxAttempts && err != nil && isTransientError(err); retry++ {
+		backoff := p.backoff(retry)
+		log.Printf("Retrying in %v after: %v\n", backoff, err)
+		time.Sleep(backoff)
+		err = call()
+	}
+	return err
+}
+
+// Parse a list of call deadlines like "2s,GetBlock=10s,PutBlocks=1m": a
+// duration without a name is the default for unary calls, named ones apply
+// to that call only. Streams only get a deadline when named.
+func ParseTimeouts(spec string) (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	if strings.TrimSpace(spec) == "" {
+		return timeouts, nil
+	}
+	for _, entry := range strings.Split(spec, TIMEOUT_DELIMITER) {
+		method, value := "", strings.TrimSpace(entry)
+		if i := strings.Index(value, TIMEOUT_ASSIGN); i >= 0 {
+			method, value = strings.TrimSpace(value[:i]), strings.TrimSpace(value[i+1:])
+			if method == "" {
+				return nil, fmt.Errorf("missing call name in %q", entry)
+			}
+			if !isRPCMethod(method) {
+				return nil, fmt.Errorf("unknown call %q in %q", method, entry)
+			}
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("timeout must be positive: %q", entry)
+		}
+		timeouts[method] = timeout
+	}
+	return timeouts, nil
+}
+
+// Whether name is a method of the BlockStore or MetaStore service
+func isRPCMethod(name string) bool {
+	for _, desc := range []grpc.ServiceDesc{BlockStore_ServiceDesc, MetaStore_ServiceDesc} {
+		for _, method := range desc.Methods {
+			if method.MethodName == name {
+				return true
+			}
+		}
+		for _, stream := range desc.Streams {
+			if stream.StreamName == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Context for a call of method with its configured deadline. Unary calls
+// always get one; streams carry any number of blocks, so they only get a
+// deadline configured for them by name.
+func (surfClient *RPCClient) callContext(ctx context.Context, method string, stream bool) (context.Context, context.CancelFunc) {
+	if timeout, ok := surfClient.Timeouts[method]; ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+	if stream {
+		return context.WithCancel(ctx)
+	}
+	if timeout, ok := surfClient.Timeouts[""]; ok {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithTimeout(ctx, DEFAULT_RPC_TIMEOUT)
+}
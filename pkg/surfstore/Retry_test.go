@@ -0,0 +1,134 @@
+package surfstore
+
+import (
+	context "context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"unavailable", status.Error(codes.Unavailable, "down"), true},
+		{"deadline", status.Error(codes.DeadlineExceeded, "slow"), true},
+		{"context deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), true},
+		{"aborted", status.Error(codes.Aborted, "aborted"), true},
+		{"no leader", status.Error(codes.FailedPrecondition, "no leader"), true},
+		{"connection", errors.New("connection reset"), true},
+		{"message too large", status.Error(codes.ResourceExhausted, "grpc: received message larger than max"), false},
+		{"not found", status.Error(codes.NotFound, "no block"), false},
+		{"permission", status.Error(codes.PermissionDenied, "read-only"), false},
+		{"invalid", status.Error(codes.InvalidArgument, "bad hash"), false},
+		{"client closed", errClientClosed, false},
+		{"classified transient", &TransientError{Err: status.Error(codes.NotFound, "")}, true},
+		{"classified permanent", &PermanentError{Err: status.Error(codes.Unavailable, "")}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if isTransientError(test.err) != test.transient {
+				t.Errorf("isTransientError(%v) = %v", test.err, !test.transient)
+			}
+			if IsTransient(newRPCError("GetBlock", "localhost:8081", test.err)) != test.transient {
+				t.Errorf("%v classified as transient = %v", test.err, !test.transient)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	tests := []struct {
+		retry int
+		full  time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			if backoff := policy.backoff(test.retry); backoff < test.full/2 || backoff > test.full {
+				t.Fatalf("backoff before retry %v is %v, expected between %v and %v", test.retry, backoff, test.full/2, test.full)
+			}
+		}
+	}
+}
+
+func TestRetryRun(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1}
+	unavailable := status.Error(codes.Unavailable, "down")
+	tests := []struct {
+		name     string
+		policy   *RetryPolicy
+		errs     []error
+		attempts int
+		ok       bool
+	}{
+		{"success", policy, []error{nil}, 1, true},
+		{"recovers", policy, []error{unavailable, unavailable, nil}, 3, true},
+		{"attempts used up", policy, []error{unavailable, unavailable, unavailable, nil}, 3, false},
+		{"permanent", policy, []error{status.Error(codes.NotFound, "no block"), nil}, 1, false},
+		{"no policy", nil, []error{unavailable, nil}, 1, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			err := test.policy.run(func() error {
+				attempts++
+				return test.errs[attempts-1]
+			})
+			if attempts != test.attempts || (err == nil) != test.ok {
+				t.Errorf("%v attempts, error %v; expected %v attempts", attempts, err, test.attempts)
+			}
+		})
+	}
+}
+
+func TestParseTimeouts(t *testing.T) {
+	tests := []struct {
+		spec     string
+		timeouts map[string]time.Duration
+		ok       bool
+	}{
+		{"", map[string]time.Duration{}, true},
+		{"5s", map[string]time.Duration{"": 5 * time.Second}, true},
+		{"2s, GetBlock=10s,PutBlocks=1m", map[string]time.Duration{"": 2 * time.Second, "GetBlock": 10 * time.Second, "PutBlocks": time.Minute}, true},
+		{"WatchChanges=1h", map[string]time.Duration{"WatchChanges": time.Hour}, true},
+		{"GetBlok=5s", nil, false},
+		{"=5s", nil, false},
+		{"GetBlock=", nil, false},
+		{"GetBlock=-1s", nil, false},
+		{"0s", nil, false},
+		{"5", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			timeouts, err := ParseTimeouts(test.spec)
+			if (err == nil) != test.ok {
+				t.Fatalf("ParseTimeouts(%q): %v", test.spec, err)
+			}
+			if !test.ok {
+				return
+			}
+			if len(timeouts) != len(test.timeouts) {
+				t.Fatalf("ParseTimeouts(%q) = %v, expected %v", test.spec, timeouts, test.timeouts)
+			}
+			for method, timeout := range test.timeouts {
+				if timeouts[method] != timeout {
+					t.Errorf("ParseTimeouts(%q) = %v, expected %v", test.spec, timeouts, test.timeouts)
+				}
+			}
+		})
+	}
+}
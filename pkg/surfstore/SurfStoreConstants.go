@@ -4,6 +4,7 @@ const DEFAULT_META_FILENAME string = "index.db"
 
 const TOMBSTONE_HASHVALUE string = "0"
 const EMPTYFILE_HASHVALUE string = "-1"
+const DIRECTORY_HASHVALUE string = "-2"
 
 const FILENAME_INDEX int = 0
 const VERSION_INDEX int = 1
@@ -11,3 +12,13 @@ const HASH_LIST_INDEX int = 2
 
 const CONFIG_DELIMITER string = ","
 const HASH_DELIMITER string = " "
+
+// Number of blocks the client sends over one upload stream
+const UPLOAD_BATCH_SIZE int = 256
+
+// Number of blocks the client fetches before writing them out
+const DOWNLOAD_BATCH_SIZE int = 256
+
+// Ends the names of the temporary files downloads are written to, which
+// are never synced
+const DOWNLOAD_TEMP_SUFFIX string = ".surfstore-download"
@@ -27,6 +27,11 @@ type BlockStoreClient interface {
 	PutBlock(ctx context.Context, in *Block, opts ...grpc.CallOption) (*Success, error)
 	HasBlocks(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (*BlockHashes, error)
 	GetBlockHashes(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BlockHashes, error)
+	ListBlockHashes(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (BlockStore_ListBlockHashesClient, error)
+	DeleteBlocks(ctx context.Context, in *DeleteBlocksInput, opts ...grpc.CallOption) (*BlockHashes, error)
+	PutBlocks(ctx context.Context, opts ...grpc.CallOption) (BlockStore_PutBlocksClient, error)
+	GetBlocks(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (BlockStore_GetBlocksClient, error)
+	GetBlocksStream(ctx context.Context, opts ...grpc.CallOption) (BlockStore_GetBlocksStreamClient, error)
 }
 
 type blockStoreClient struct {
@@ -73,6 +78,144 @@ func (c *blockStoreClient) GetBlockHashes(ctx context.Context, in *emptypb.Empty
 	return out, nil
 }
 
+func (c *blockStoreClient) ListBlockHashes(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (BlockStore_ListBlockHashesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BlockStore_ServiceDesc.Streams[0], "/surfstore.BlockStore/ListBlockHashes", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &blockStoreListBlockHashesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BlockStore_ListBlockHashesClient interface {
+	Recv() (*BlockHashes, error)
+	grpc.ClientStream
+}
+
+type blockStoreListBlockHashesClient struct {
+	grpc.ClientStream
+}
+
+func (x *blockStoreListBlockHashesClient) Recv() (*BlockHashes, error) {
+	m := new(BlockHashes)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *blockStoreClient) DeleteBlocks(ctx context.Context, in *DeleteBlocksInput, opts ...grpc.CallOption) (*BlockHashes, error) {
+	out := new(BlockHashes)
+	err := c.cc.Invoke(ctx, "/surfstore.BlockStore/DeleteBlocks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blockStoreClient) PutBlocks(ctx context.Context, opts ...grpc.CallOption) (BlockStore_PutBlocksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BlockStore_ServiceDesc.Streams[1], "/surfstore.BlockStore/PutBlocks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &blockStorePutBlocksClient{stream}
+	return x, nil
+}
+
+type BlockStore_PutBlocksClient interface {
+	Send(*Block) error
+	CloseAndRecv() (*Success, error)
+	grpc.ClientStream
+}
+
+type blockStorePutBlocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *blockStorePutBlocksClient) Send(m *Block) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *blockStorePutBlocksClient) CloseAndRecv() (*Success, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Success)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *blockStoreClient) GetBlocks(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (BlockStore_GetBlocksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BlockStore_ServiceDesc.Streams[2], "/surfstore.BlockStore/GetBlocks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &blockStoreGetBlocksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BlockStore_GetBlocksClient interface {
+	Recv() (*Block, error)
+	grpc.ClientStream
+}
+
+type blockStoreGetBlocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *blockStoreGetBlocksClient) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *blockStoreClient) GetBlocksStream(ctx context.Context, opts ...grpc.CallOption) (BlockStore_GetBlocksStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BlockStore_ServiceDesc.Streams[3], "/surfstore.BlockStore/GetBlocksStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &blockStoreGetBlocksStreamClient{stream}
+	return x, nil
+}
+
+type BlockStore_GetBlocksStreamClient interface {
+	Send(*BlockHash) error
+	Recv() (*Block, error)
+	grpc.ClientStream
+}
+
+type blockStoreGetBlocksStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *blockStoreGetBlocksStreamClient) Send(m *BlockHash) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *blockStoreGetBlocksStreamClient) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BlockStoreServer is the server API for BlockStore service.
 // All implementations must embed UnimplementedBlockStoreServer
 // for forward compatibility
@@ -81,6 +224,11 @@ type BlockStoreServer interface {
 	PutBlock(context.Context, *Block) (*Success, error)
 	HasBlocks(context.Context, *BlockHashes) (*BlockHashes, error)
 	GetBlockHashes(context.Context, *emptypb.Empty) (*BlockHashes, error)
+	ListBlockHashes(*emptypb.Empty, BlockStore_ListBlockHashesServer) error
+	DeleteBlocks(context.Context, *DeleteBlocksInput) (*BlockHashes, error)
+	PutBlocks(BlockStore_PutBlocksServer) error
+	GetBlocks(*BlockHashes, BlockStore_GetBlocksServer) error
+	GetBlocksStream(BlockStore_GetBlocksStreamServer) error
 	mustEmbedUnimplementedBlockStoreServer()
 }
 
@@ -100,6 +248,21 @@ func (UnimplementedBlockStoreServer) HasBlocks(context.Context, *BlockHashes) (*
 func (UnimplementedBlockStoreServer) GetBlockHashes(context.Context, *emptypb.Empty) (*BlockHashes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlockHashes not implemented")
 }
+func (UnimplementedBlockStoreServer) ListBlockHashes(*emptypb.Empty, BlockStore_ListBlockHashesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListBlockHashes not implemented")
+}
+func (UnimplementedBlockStoreServer) DeleteBlocks(context.Context, *DeleteBlocksInput) (*BlockHashes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBlocks not implemented")
+}
+func (UnimplementedBlockStoreServer) PutBlocks(BlockStore_PutBlocksServer) error {
+	return status.Errorf(codes.Unimplemented, "method PutBlocks not implemented")
+}
+func (UnimplementedBlockStoreServer) GetBlocks(*BlockHashes, BlockStore_GetBlocksServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetBlocks not implemented")
+}
+func (UnimplementedBlockStoreServer) GetBlocksStream(BlockStore_GetBlocksStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetBlocksStream not implemented")
+}
 func (UnimplementedBlockStoreServer) mustEmbedUnimplementedBlockStoreServer() {}
 
 // UnsafeBlockStoreServer may be embedded to opt out of forward compatibility for this service.
@@ -185,6 +348,118 @@ func _BlockStore_GetBlockHashes_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BlockStore_ListBlockHashes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BlockStoreServer).ListBlockHashes(m, &blockStoreListBlockHashesServer{stream})
+}
+
+type BlockStore_ListBlockHashesServer interface {
+	Send(*BlockHashes) error
+	grpc.ServerStream
+}
+
+type blockStoreListBlockHashesServer struct {
+	grpc.ServerStream
+}
+
+func (x *blockStoreListBlockHashesServer) Send(m *BlockHashes) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BlockStore_DeleteBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBlocksInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockStoreServer).DeleteBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.BlockStore/DeleteBlocks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockStoreServer).DeleteBlocks(ctx, req.(*DeleteBlocksInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlockStore_PutBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BlockStoreServer).PutBlocks(&blockStorePutBlocksServer{stream})
+}
+
+type BlockStore_PutBlocksServer interface {
+	SendAndClose(*Success) error
+	Recv() (*Block, error)
+	grpc.ServerStream
+}
+
+type blockStorePutBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *blockStorePutBlocksServer) SendAndClose(m *Success) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *blockStorePutBlocksServer) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _BlockStore_GetBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BlockHashes)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BlockStoreServer).GetBlocks(m, &blockStoreGetBlocksServer{stream})
+}
+
+type BlockStore_GetBlocksServer interface {
+	Send(*Block) error
+	grpc.ServerStream
+}
+
+type blockStoreGetBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *blockStoreGetBlocksServer) Send(m *Block) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BlockStore_GetBlocksStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BlockStoreServer).GetBlocksStream(&blockStoreGetBlocksStreamServer{stream})
+}
+
+type BlockStore_GetBlocksStreamServer interface {
+	Send(*Block) error
+	Recv() (*BlockHash, error)
+	grpc.ServerStream
+}
+
+type blockStoreGetBlocksStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *blockStoreGetBlocksStreamServer) Send(m *Block) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *blockStoreGetBlocksStreamServer) Recv() (*BlockHash, error) {
+	m := new(BlockHash)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BlockStore_ServiceDesc is the grpc.ServiceDesc for BlockStore service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -208,8 +483,34 @@ var BlockStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBlockHashes",
 			Handler:    _BlockStore_GetBlockHashes_Handler,
 		},
+		{
+			MethodName: "DeleteBlocks",
+			Handler:    _BlockStore_DeleteBlocks_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListBlockHashes",
+			Handler:       _BlockStore_ListBlockHashes_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PutBlocks",
+			Handler:       _BlockStore_PutBlocks_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetBlocks",
+			Handler:       _BlockStore_GetBlocks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetBlocksStream",
+			Handler:       _BlockStore_GetBlocksStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/surfstore/SurfStore.proto",
 }
 
@@ -221,6 +522,17 @@ type MetaStoreClient interface {
 	UpdateFile(ctx context.Context, in *FileMetaData, opts ...grpc.CallOption) (*Version, error)
 	GetBlockStoreMap(ctx context.Context, in *BlockHashes, opts ...grpc.CallOption) (*BlockStoreMap, error)
 	GetBlockStoreAddrs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BlockStoreAddrs, error)
+	AddBlockStore(ctx context.Context, in *BlockStoreAddr, opts ...grpc.CallOption) (*Success, error)
+	RemoveBlockStore(ctx context.Context, in *BlockStoreAddr, opts ...grpc.CallOption) (*Success, error)
+	ShareFolder(ctx context.Context, in *Share, opts ...grpc.CallOption) (*Success, error)
+	UnshareFolder(ctx context.Context, in *Share, opts ...grpc.CallOption) (*Success, error)
+	GetShares(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Shares, error)
+	WatchChanges(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (MetaStore_WatchChangesClient, error)
+	GetChangesSince(ctx context.Context, in *ChangesRequest, opts ...grpc.CallOption) (*Changes, error)
+	GetFileVersions(ctx context.Context, in *VersionsRequest, opts ...grpc.CallOption) (*FileVersions, error)
+	GetFileVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*FileMetaData, error)
+	GetTrash(ctx context.Context, in *TrashRequest, opts ...grpc.CallOption) (*Trash, error)
+	Undelete(ctx context.Context, in *TrashRequest, opts ...grpc.CallOption) (*Trash, error)
 }
 
 type metaStoreClient struct {
@@ -267,6 +579,128 @@ func (c *metaStoreClient) GetBlockStoreAddrs(ctx context.Context, in *emptypb.Em
 	return out, nil
 }
 
+func (c *metaStoreClient) AddBlockStore(ctx context.Context, in *BlockStoreAddr, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/AddBlockStore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) RemoveBlockStore(ctx context.Context, in *BlockStoreAddr, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/RemoveBlockStore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) ShareFolder(ctx context.Context, in *Share, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/ShareFolder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) UnshareFolder(ctx context.Context, in *Share, opts ...grpc.CallOption) (*Success, error) {
+	out := new(Success)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/UnshareFolder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) GetShares(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Shares, error) {
+	out := new(Shares)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/GetShares", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) WatchChanges(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (MetaStore_WatchChangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MetaStore_ServiceDesc.Streams[0], "/surfstore.MetaStore/WatchChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &metaStoreWatchChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MetaStore_WatchChangesClient interface {
+	Recv() (*FileChange, error)
+	grpc.ClientStream
+}
+
+type metaStoreWatchChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *metaStoreWatchChangesClient) Recv() (*FileChange, error) {
+	m := new(FileChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *metaStoreClient) GetChangesSince(ctx context.Context, in *ChangesRequest, opts ...grpc.CallOption) (*Changes, error) {
+	out := new(Changes)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/GetChangesSince", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) GetFileVersions(ctx context.Context, in *VersionsRequest, opts ...grpc.CallOption) (*FileVersions, error) {
+	out := new(FileVersions)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/GetFileVersions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) GetFileVersion(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*FileMetaData, error) {
+	out := new(FileMetaData)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/GetFileVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) GetTrash(ctx context.Context, in *TrashRequest, opts ...grpc.CallOption) (*Trash, error) {
+	out := new(Trash)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/GetTrash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metaStoreClient) Undelete(ctx context.Context, in *TrashRequest, opts ...grpc.CallOption) (*Trash, error) {
+	out := new(Trash)
+	err := c.cc.Invoke(ctx, "/surfstore.MetaStore/Undelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetaStoreServer is the server API for MetaStore service.
 // All implementations must embed UnimplementedMetaStoreServer
 // for forward compatibility
@@ -275,6 +709,17 @@ type MetaStoreServer interface {
 	UpdateFile(context.Context, *FileMetaData) (*Version, error)
 	GetBlockStoreMap(context.Context, *BlockHashes) (*BlockStoreMap, error)
 	GetBlockStoreAddrs(context.Context, *emptypb.Empty) (*BlockStoreAddrs, error)
+	AddBlockStore(context.Context, *BlockStoreAddr) (*Success, error)
+	RemoveBlockStore(context.Context, *BlockStoreAddr) (*Success, error)
+	ShareFolder(context.Context, *Share) (*Success, error)
+	UnshareFolder(context.Context, *Share) (*Success, error)
+	GetShares(context.Context, *emptypb.Empty) (*Shares, error)
+	WatchChanges(*WatchRequest, MetaStore_WatchChangesServer) error
+	GetChangesSince(context.Context, *ChangesRequest) (*Changes, error)
+	GetFileVersions(context.Context, *VersionsRequest) (*FileVersions, error)
+	GetFileVersion(context.Context, *VersionRequest) (*FileMetaData, error)
+	GetTrash(context.Context, *TrashRequest) (*Trash, error)
+	Undelete(context.Context, *TrashRequest) (*Trash, error)
 	mustEmbedUnimplementedMetaStoreServer()
 }
 
@@ -294,6 +739,39 @@ func (UnimplementedMetaStoreServer) GetBlockStoreMap(context.Context, *BlockHash
 func (UnimplementedMetaStoreServer) GetBlockStoreAddrs(context.Context, *emptypb.Empty) (*BlockStoreAddrs, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlockStoreAddrs not implemented")
 }
+func (UnimplementedMetaStoreServer) AddBlockStore(context.Context, *BlockStoreAddr) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBlockStore not implemented")
+}
+func (UnimplementedMetaStoreServer) RemoveBlockStore(context.Context, *BlockStoreAddr) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveBlockStore not implemented")
+}
+func (UnimplementedMetaStoreServer) ShareFolder(context.Context, *Share) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareFolder not implemented")
+}
+func (UnimplementedMetaStoreServer) UnshareFolder(context.Context, *Share) (*Success, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnshareFolder not implemented")
+}
+func (UnimplementedMetaStoreServer) GetShares(context.Context, *emptypb.Empty) (*Shares, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShares not implemented")
+}
+func (UnimplementedMetaStoreServer) WatchChanges(*WatchRequest, MetaStore_WatchChangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchChanges not implemented")
+}
+func (UnimplementedMetaStoreServer) GetChangesSince(context.Context, *ChangesRequest) (*Changes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChangesSince not implemented")
+}
+func (UnimplementedMetaStoreServer) GetFileVersions(context.Context, *VersionsRequest) (*FileVersions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFileVersions not implemented")
+}
+func (UnimplementedMetaStoreServer) GetFileVersion(context.Context, *VersionRequest) (*FileMetaData, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFileVersion not implemented")
+}
+func (UnimplementedMetaStoreServer) GetTrash(context.Context, *TrashRequest) (*Trash, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrash not implemented")
+}
+func (UnimplementedMetaStoreServer) Undelete(context.Context, *TrashRequest) (*Trash, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Undelete not implemented")
+}
 func (UnimplementedMetaStoreServer) mustEmbedUnimplementedMetaStoreServer() {}
 
 // UnsafeMetaStoreServer may be embedded to opt out of forward compatibility for this service.
@@ -379,6 +857,207 @@ func _MetaStore_GetBlockStoreAddrs_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MetaStore_AddBlockStore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockStoreAddr)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).AddBlockStore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/AddBlockStore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).AddBlockStore(ctx, req.(*BlockStoreAddr))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_RemoveBlockStore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockStoreAddr)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).RemoveBlockStore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/RemoveBlockStore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).RemoveBlockStore(ctx, req.(*BlockStoreAddr))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_ShareFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Share)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).ShareFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/ShareFolder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).ShareFolder(ctx, req.(*Share))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_UnshareFolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Share)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).UnshareFolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/UnshareFolder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).UnshareFolder(ctx, req.(*Share))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_GetShares_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).GetShares(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/GetShares",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).GetShares(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_WatchChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MetaStoreServer).WatchChanges(m, &metaStoreWatchChangesServer{stream})
+}
+
+type MetaStore_WatchChangesServer interface {
+	Send(*FileChange) error
+	grpc.ServerStream
+}
+
+type metaStoreWatchChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *metaStoreWatchChangesServer) Send(m *FileChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MetaStore_GetChangesSince_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).GetChangesSince(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/GetChangesSince",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).GetChangesSince(ctx, req.(*ChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_GetFileVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).GetFileVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/GetFileVersions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).GetFileVersions(ctx, req.(*VersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_GetFileVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).GetFileVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/GetFileVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).GetFileVersion(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_GetTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).GetTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/GetTrash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).GetTrash(ctx, req.(*TrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetaStore_Undelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetaStoreServer).Undelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.MetaStore/Undelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetaStoreServer).Undelete(ctx, req.(*TrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MetaStore_ServiceDesc is the grpc.ServiceDesc for MetaStore service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -402,6 +1081,210 @@ var MetaStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBlockStoreAddrs",
 			Handler:    _MetaStore_GetBlockStoreAddrs_Handler,
 		},
+		{
+			MethodName: "AddBlockStore",
+			Handler:    _MetaStore_AddBlockStore_Handler,
+		},
+		{
+			MethodName: "RemoveBlockStore",
+			Handler:    _MetaStore_RemoveBlockStore_Handler,
+		},
+		{
+			MethodName: "ShareFolder",
+			Handler:    _MetaStore_ShareFolder_Handler,
+		},
+		{
+			MethodName: "UnshareFolder",
+			Handler:    _MetaStore_UnshareFolder_Handler,
+		},
+		{
+			MethodName: "GetShares",
+			Handler:    _MetaStore_GetShares_Handler,
+		},
+		{
+			MethodName: "GetChangesSince",
+			Handler:    _MetaStore_GetChangesSince_Handler,
+		},
+		{
+			MethodName: "GetFileVersions",
+			Handler:    _MetaStore_GetFileVersions_Handler,
+		},
+		{
+			MethodName: "GetFileVersion",
+			Handler:    _MetaStore_GetFileVersion_Handler,
+		},
+		{
+			MethodName: "GetTrash",
+			Handler:    _MetaStore_GetTrash_Handler,
+		},
+		{
+			MethodName: "Undelete",
+			Handler:    _MetaStore_Undelete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchChanges",
+			Handler:       _MetaStore_WatchChanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/surfstore/SurfStore.proto",
+}
+
+// RaftSurfstoreClient is the client API for RaftSurfstore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RaftSurfstoreClient interface {
+	AppendEntries(ctx context.Context, in *AppendEntryInput, opts ...grpc.CallOption) (*AppendEntryOutput, error)
+	RequestVote(ctx context.Context, in *RequestVoteInput, opts ...grpc.CallOption) (*RequestVoteOutput, error)
+	InstallSnapshot(ctx context.Context, in *InstallSnapshotInput, opts ...grpc.CallOption) (*AppendEntryOutput, error)
+}
+
+type raftSurfstoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRaftSurfstoreClient(cc grpc.ClientConnInterface) RaftSurfstoreClient {
+	return &raftSurfstoreClient{cc}
+}
+
+func (c *raftSurfstoreClient) AppendEntries(ctx context.Context, in *AppendEntryInput, opts ...grpc.CallOption) (*AppendEntryOutput, error) {
+	out := new(AppendEntryOutput)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/AppendEntries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) RequestVote(ctx context.Context, in *RequestVoteInput, opts ...grpc.CallOption) (*RequestVoteOutput, error) {
+	out := new(RequestVoteOutput)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/RequestVote", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftSurfstoreClient) InstallSnapshot(ctx context.Context, in *InstallSnapshotInput, opts ...grpc.CallOption) (*AppendEntryOutput, error) {
+	out := new(AppendEntryOutput)
+	err := c.cc.Invoke(ctx, "/surfstore.RaftSurfstore/InstallSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RaftSurfstoreServer is the server API for RaftSurfstore service.
+// All implementations must embed UnimplementedRaftSurfstoreServer
+// for forward compatibility
+type RaftSurfstoreServer interface {
+	AppendEntries(context.Context, *AppendEntryInput) (*AppendEntryOutput, error)
+	RequestVote(context.Context, *RequestVoteInput) (*RequestVoteOutput, error)
+	InstallSnapshot(context.Context, *InstallSnapshotInput) (*AppendEntryOutput, error)
+	mustEmbedUnimplementedRaftSurfstoreServer()
+}
+
+// UnimplementedRaftSurfstoreServer must be embedded to have forward compatible implementations.
+type UnimplementedRaftSurfstoreServer struct {
+}
+
+func (UnimplementedRaftSurfstoreServer) AppendEntries(context.Context, *AppendEntryInput) (*AppendEntryOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendEntries not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) RequestVote(context.Context, *RequestVoteInput) (*RequestVoteOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestVote not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) InstallSnapshot(context.Context, *InstallSnapshotInput) (*AppendEntryOutput, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
+func (UnimplementedRaftSurfstoreServer) mustEmbedUnimplementedRaftSurfstoreServer() {}
+
+// UnsafeRaftSurfstoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RaftSurfstoreServer will
+// result in compilation errors.
+type UnsafeRaftSurfstoreServer interface {
+	mustEmbedUnimplementedRaftSurfstoreServer()
+}
+
+func RegisterRaftSurfstoreServer(s grpc.ServiceRegistrar, srv RaftSurfstoreServer) {
+	s.RegisterService(&RaftSurfstore_ServiceDesc, srv)
+}
+
+func _RaftSurfstore_AppendEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendEntryInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).AppendEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/AppendEntries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).AppendEntries(ctx, req.(*AppendEntryInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_RequestVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestVoteInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).RequestVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/RequestVote",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).RequestVote(ctx, req.(*RequestVoteInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RaftSurfstore_InstallSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstallSnapshotInput)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftSurfstoreServer).InstallSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/surfstore.RaftSurfstore/InstallSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftSurfstoreServer).InstallSnapshot(ctx, req.(*InstallSnapshotInput))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RaftSurfstore_ServiceDesc is the grpc.ServiceDesc for RaftSurfstore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RaftSurfstore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "surfstore.RaftSurfstore",
+	HandlerType: (*RaftSurfstoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AppendEntries",
+			Handler:    _RaftSurfstore_AppendEntries_Handler,
+		},
+		{
+			MethodName: "RequestVote",
+			Handler:    _RaftSurfstore_RequestVote_Handler,
+		},
+		{
+			MethodName: "InstallSnapshot",
+			Handler:    _RaftSurfstore_InstallSnapshot_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/surfstore/SurfStore.proto",
@@ -0,0 +1,306 @@
+package surfstore
+
+import (
+	context "context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	sync "sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// How long the daemon waits for a burst of local changes to settle before
+// syncing them, and how often it asks the MetaStore for remote changes
+const DEFAULT_DEBOUNCE = 500 * time.Millisecond
+const DEFAULT_POLL_INTERVAL = 10 * time.Second
+
+// How long the daemon takes watch events for a path a sync pass wrote to be
+// the pass's own, as long as the path is still as the pass left it
+const OWN_WRITE_EXPIRY = 10 * time.Second
+
+// ClientDaemon keeps the client's base directory in sync until ctx is done.
+// It starts with a full sync pass. Local changes are picked up by watching
+// the directory tree; once no further change arrived for debounce, a pass
+// hashes just the changed files. Remote changes are pushed by the MetaStore
+// over WatchChanges and synced the same way. As a fallback, for instance
+// while the change stream is down, the MetaStore is asked every poll whether
+// anything changed since the last pass. A failed pass is retried at the next
+// poll. Both start from the cursor the last sync stored in the index. The
+// files a pass downloads, removes or moves aside as conflict copies are not
+// taken for local changes.
+func ClientDaemon(ctx context.Context, client RPCClient, debounce time.Duration, poll time.Duration) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watchTree(watcher, client.BaseDir); err != nil {
+		return err
+	}
+	epoch, cursor, err := LoadCursor(client.BaseDir, strings.Join(client.MetaStoreAddrs, CONFIG_DELIMITER))
+	if err != nil {
+		return err
+	}
+	client.writes = newOwnWrites(client.BaseDir)
+
+	remoteChanged := make(chan struct{}, 1)
+	go watchRemoteChanges(ctx, client, epoch, cursor, poll, remoteChanged)
+
+	// local paths changed since the last pass, or everything after a failed
+	// full pass or lost events
+	pending := map[string]bool{}
+	full := true
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			filename, ok := watchedName(client.BaseDir, event.Name)
+			if !ok {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				// directories created or moved in need watches of their own
+				if info, err := os.Lstat(event.Name); err == nil && info.IsDir() {
+					if err := watchTree(watcher, event.Name); err != nil {
+						log.Println("Could not watch directory: ", err)
+						full = true
+					}
+				}
+			}
+			if client.writes.isOwn(filename) {
+				continue
+			}
+			pending[filename] = true
+			resetTimer(timer, debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Println("Watch error, rescanning: ", err)
+			full = true
+			resetTimer(timer, debounce)
+
+		case <-remoteChanged:
+			resetTimer(timer, debounce)
+
+		case <-ticker.C:
+			if !full && len(pending) == 0 {
+				changed, err := hasRemoteChanges(client, epoch, cursor)
+				if err != nil {
+					log.Println("Could not poll MetaStore: ", err)
+					continue
+				} else if !changed {
+					continue
+				}
+			}
+			resetTimer(timer, 0)
+
+		case <-timer.C:
+			changed := pending
+			if full {
+				changed = nil
+			}
+			pending = map[string]bool{}
+			syncedEpoch, synced, err := clientSync(client, changed)
+			if err != nil {
+				if IsTransient(err) {
+					log.Println("Sync failed, retrying: ", err)
+				} else {
+					fmt.Fprintln(os.Stderr, "Sync failed, retrying: ", err)
+				}
+				for filename := range changed {
+					pending[filename] = true
+				}
+				full = full || changed == nil
+				continue
+			}
+			full = false
+			epoch, cursor = syncedEpoch, synced
+		}
+	}
+}
+
+// Signal remoteChanged whenever a file changes on the MetaStore after
+// sequence number since of the given epoch, until ctx is done. A broken
+// change stream is opened again after retryInterval, resuming where it
+// stopped; servers without change streams are left to polling.
+func watchRemoteChanges(ctx context.Context, client RPCClient, epoch string, since int64, retryInterval time.Duration, remoteChanged chan<- struct{}) {
+	for {
+		err := client.WatchChanges(ctx, epoch, since, func(change *FileChange) error {
+			if change.FileMetaData == nil {
+				epoch = change.Epoch
+			}
+			since = change.Sequence
+			if change.FileMetaData != nil || change.Resync {
+				signal(remoteChanged)
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if status.Code(err) == codes.Unimplemented {
+			log.Println("MetaStore does not stream changes, polling only")
+			return
+		}
+		log.Println("Change stream broken: ", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func signal(c chan<- struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// Whether anything changed on the MetaStore after the cursor of the given
+// epoch
+func hasRemoteChanges(client RPCClient, epoch string, cursor int64) (bool, error) {
+	var changes Changes
+	err := client.GetChangesSince(epoch, cursor, 1, &changes)
+	if status.Code(err) == codes.Unimplemented {
+		// a MetaStore without change cursors is synced every time
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return changes.Resync || changes.HasMore || len(changes.Changes) > 0, nil
+}
+
+// Watch dir and every directory below it
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			// removed while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
+// Name of a changed path relative to baseDir, as used in the index. Changes
+// to the base directory itself, to the index and to downloads in progress
+// are ignored.
+func watchedName(baseDir string, path string) (string, bool) {
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	// sqlite also writes a journal next to the index
+	if rel == DEFAULT_META_FILENAME || strings.HasPrefix(rel, DEFAULT_META_FILENAME+"-") || strings.HasSuffix(rel, DOWNLOAD_TEMP_SUFFIX) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// ownWrites remembers the paths below baseDir a sync pass wrote to, and
+// what it left there, so that their watch events can be told from local
+// changes. A nil ownWrites records nothing.
+type ownWrites struct {
+	baseDir string
+
+	mtx   sync.Mutex
+	paths map[string]ownWrite
+}
+
+type ownWrite struct {
+	at     time.Time
+	exists bool
+	isDir  bool
+	size   int64
+	mtime  time.Time
+}
+
+func newOwnWrites(baseDir string) *ownWrites {
+	return &ownWrites{baseDir: baseDir, paths: map[string]ownWrite{}}
+}
+
+// Remember the state a sync pass left filename in, once done writing it
+func (w *ownWrites) record(filename string) {
+	if w == nil {
+		return
+	}
+	write := w.stat(filename)
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	for name, old := range w.paths {
+		if time.Since(old.at) > OWN_WRITE_EXPIRY {
+			delete(w.paths, name)
+		}
+	}
+	w.paths[filename] = write
+}
+
+// Whether filename is still as a sync pass recently left it, so a watch
+// event for it came from the pass. Directories match as long as they
+// exist, since the files a pass writes in them change them too.
+func (w *ownWrites) isOwn(filename string) bool {
+	if w == nil {
+		return false
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	write, ok := w.paths[filename]
+	if !ok {
+		return false
+	}
+	current := w.stat(filename)
+	if time.Since(write.at) > OWN_WRITE_EXPIRY || current.exists != write.exists || current.isDir != write.isDir ||
+		(!current.isDir && (current.size != write.size || !current.mtime.Equal(write.mtime))) {
+		delete(w.paths, filename)
+		return false
+	}
+	return true
+}
+
+func (w *ownWrites) stat(filename string) ownWrite {
+	write := ownWrite{at: time.Now()}
+	if info, err := os.Lstat(filepath.Join(w.baseDir, filepath.FromSlash(filename))); err == nil {
+		write.exists = true
+		write.isDir = info.IsDir()
+		write.size = info.Size()
+		write.mtime = info.ModTime()
+	}
+	return write
+}
+
+// Make timer fire after d, whether or not it is running
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
@@ -0,0 +1,49 @@
+package surfstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDaemonIgnoresOwnWrites(t *testing.T) {
+	addr := startTestServer(t, NewBlockStore())
+	a := newTestClient(t, addr, "a")
+	b := newTestClient(t, addr, "b")
+	b.writes = newOwnWrites(b.BaseDir)
+
+	writeTestFile(t, a, "notes.txt", "first")
+	writeTestFile(t, a, "gone.txt", "gone")
+	syncTestClient(t, a)
+	syncTestClient(t, b)
+	if err := os.Remove(filepath.Join(a.BaseDir, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	syncTestClient(t, a)
+	syncTestClient(t, b)
+
+	// the files the passes downloaded and removed
+	for _, filename := range []string{"notes.txt", "gone.txt"} {
+		if !b.writes.isOwn(filename) {
+			t.Errorf("%v not taken for the daemon's own write", filename)
+		}
+	}
+	// until they are changed locally
+	writeTestFile(t, b, "notes.txt", "local edit")
+	writeTestFile(t, b, "gone.txt", "back")
+	for _, filename := range []string{"notes.txt", "gone.txt"} {
+		if b.writes.isOwn(filename) {
+			t.Errorf("local change to %v taken for the daemon's own write", filename)
+		}
+	}
+
+	// the index and downloads in progress are never watched
+	for _, name := range []string{DEFAULT_META_FILENAME, DEFAULT_META_FILENAME + "-journal", ".notes.txt.1" + DOWNLOAD_TEMP_SUFFIX} {
+		if filename, ok := watchedName(b.BaseDir, filepath.Join(b.BaseDir, name)); ok {
+			t.Errorf("change to %v watched as %v", name, filename)
+		}
+	}
+	if filename, ok := watchedName(b.BaseDir, filepath.Join(b.BaseDir, "dir", "notes.txt")); !ok || filename != "dir/notes.txt" {
+		t.Errorf("change to dir/notes.txt watched as %q, %v", filename, ok)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -29,6 +30,36 @@ func ConcatPath(baseDir, fileDir string) string {
 	return baseDir + "/" + fileDir
 }
 
+// writeFileAtomic writes data to a temporary file next to path, fsyncs it
+// and renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// make the rename itself durable
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
 /*
 	Writing Local Metadata File Related
 */
@@ -94,6 +125,56 @@ func WriteMetaFile(fileMetas map[string]*FileMetaData, baseDir string) error {
 	return nil
 }
 
+const createCursorTable string = `create table if not exists changeCursors (
+		metaStore TEXT,
+		epoch TEXT,
+		cursor INT
+	);`
+
+const insertCursor = `INSERT INTO changeCursors (metaStore, epoch, cursor) VALUES (?, ?, ?);`
+
+const getCursor = `SELECT epoch, cursor FROM changeCursors WHERE metaStore = ?;`
+
+// WriteCursor stores in index.db the MetaStore's change cursor the index is
+// current to, with the epoch it belongs to. WriteMetaFile starts index.db
+// afresh, so this has to follow it.
+func WriteCursor(baseDir string, metaStore string, epoch string, cursor int64) error {
+	db, err := sql.Open("sqlite3", ConcatPath(baseDir, DEFAULT_META_FILENAME))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.Exec(createCursorTable); err != nil {
+		return err
+	}
+	_, err = db.Exec(insertCursor, metaStore, epoch, cursor)
+	return err
+}
+
+// LoadCursor returns the epoch and cursor WriteCursor stored for
+// metaStore, or "" and 0 if there is none.
+func LoadCursor(baseDir string, metaStore string) (string, int64, error) {
+	metaFilePath := ConcatPath(baseDir, DEFAULT_META_FILENAME)
+	if _, err := os.Stat(metaFilePath); err != nil {
+		return "", 0, nil
+	}
+	db, err := sql.Open("sqlite3", metaFilePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer db.Close()
+	if _, err := db.Exec(createCursorTable); err != nil {
+		return "", 0, err
+	}
+	var epoch string
+	var cursor int64
+	err = db.QueryRow(getCursor, metaStore).Scan(&epoch, &cursor)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
+	}
+	return epoch, cursor, err
+}
+
 const (
 	getDistinctFileName = `SELECT *
 						   FROM indexes
@@ -2,6 +2,7 @@ package surfstore
 
 import (
 	context "context"
+	"time"
 
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
@@ -18,6 +19,39 @@ type MetaStoreInterface interface {
 
 	// Retrieve all BlockStore Addresses
 	GetBlockStoreAddrs(ctx context.Context, _ *emptypb.Empty) (*BlockStoreAddrs, error)
+
+	// Add a BlockStore to the hash ring, or change its weight
+	AddBlockStore(ctx context.Context, blockStoreAddr *BlockStoreAddr) (*Success, error)
+
+	// Remove a BlockStore from the hash ring
+	RemoveBlockStore(ctx context.Context, blockStoreAddr *BlockStoreAddr) (*Success, error)
+
+	// Grant another user access to a folder of the caller
+	ShareFolder(ctx context.Context, share *Share) (*Success, error)
+
+	// Revoke access granted with ShareFolder
+	UnshareFolder(ctx context.Context, share *Share) (*Success, error)
+
+	// Retrieve the shares granted by and to the caller
+	GetShares(ctx context.Context, _ *emptypb.Empty) (*Shares, error)
+
+	// Stream every update to the caller's files after a sequence number
+	WatchChanges(request *WatchRequest, stream MetaStore_WatchChangesServer) error
+
+	// Retrieve a page of the caller's files that changed after a cursor
+	GetChangesSince(ctx context.Context, request *ChangesRequest) (*Changes, error)
+
+	// Retrieve the versions kept of a file, or of the files in a directory
+	GetFileVersions(ctx context.Context, request *VersionsRequest) (*FileVersions, error)
+
+	// Retrieve one of the versions kept of a file
+	GetFileVersion(ctx context.Context, request *VersionRequest) (*FileMetaData, error)
+
+	// Retrieve the deleted files in the trash
+	GetTrash(ctx context.Context, request *TrashRequest) (*Trash, error)
+
+	// Bring back deleted files from the trash
+	Undelete(ctx context.Context, request *TrashRequest) (*Trash, error)
 }
 
 type BlockStoreInterface interface {
@@ -34,6 +68,22 @@ type BlockStoreInterface interface {
 
 	// Get which blocks are on this BlockStore server
 	GetBlockHashes(ctx context.Context, _ *emptypb.Empty) (*BlockHashes, error)
+
+	// Stream back which blocks are on this BlockStore server, in pages
+	ListBlockHashes(_ *emptypb.Empty, stream BlockStore_ListBlockHashesServer) error
+
+	// Delete the given blocks unless they were written or reported present
+	// within the grace period, returning the ones deleted
+	DeleteBlocks(ctx context.Context, input *DeleteBlocksInput) (*BlockHashes, error)
+
+	// Put every block sent on the stream
+	PutBlocks(stream BlockStore_PutBlocksServer) error
+
+	// Stream back the blocks with the given hashes, in order
+	GetBlocks(blockHashes *BlockHashes, stream BlockStore_GetBlocksServer) error
+
+	// Stream back a block for every hash received
+	GetBlocksStream(stream BlockStore_GetBlocksStreamServer) error
 }
 
 type ClientInterface interface {
@@ -42,10 +92,29 @@ type ClientInterface interface {
 	UpdateFile(fileMetaData *FileMetaData, latestVersion *int32) error
 	GetBlockStoreMap(blockHashesIn []string, blockStoreMap *map[string][]string) error
 	GetBlockStoreAddrs(blockStoreAddrs *[]string) error
+	AddBlockStore(blockStoreAddr string, weight int, succ *bool) error
+	RemoveBlockStore(blockStoreAddr string, succ *bool) error
+	ShareFolder(path string, grantee string, permission Permission, succ *bool) error
+	UnshareFolder(path string, grantee string, succ *bool) error
+	GetShares(shares *[]*Share) error
+	WatchChanges(ctx context.Context, epoch string, since int64, handle func(change *FileChange) error) error
+	GetChangesSince(epoch string, cursor int64, limit int, changes *Changes) error
+	GetFileVersions(path string, versions *[]*FileMetaData) error
+	GetFileVersion(filename string, version int32, fileMetaData *FileMetaData) error
+	GetTrash(path string, files *[]*TrashedFile) error
+	Undelete(path string, files *[]*TrashedFile) error
 
 	// BlockStore
 	GetBlock(blockHash string, blockStoreAddr string, block *Block) error
 	PutBlock(block *Block, blockStoreAddr string, succ *bool) error
 	HasBlocks(blockHashesIn []string, blockStoreAddr string, blockHashesOut *[]string) error
 	GetBlockHashes(blockStoreAddr string, blockHashes *[]string) error
+	ListBlockHashes(blockStoreAddr string, blockHashes *[]string) error
+	DeleteBlocks(blockHashesIn []string, gracePeriod time.Duration, blockStoreAddr string, blockHashesOut *[]string) error
+	PutBlocks(blocks []*Block, blockStoreAddr string, succ *bool) error
+	GetBlocks(blockHashesIn []string, blockStoreAddr string, blocks *[]*Block) error
+	GetBlocksStream(blockHashesIn []string, blockStoreAddr string, blocks *[]*Block) error
+
+	// Release the connections held by the client
+	Close() error
 }
@@ -2,208 +2,790 @@ package surfstore
 
 import (
 	context "context"
+	"fmt"
+	"io"
 	log "log"
+	"os"
+	"strings"
+	sync "sync"
 	"time"
 
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	status "google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// Number of passes over the MetaStore addresses while looking for a leader,
+// and the pause between passes to let an election finish
+const LEADER_RETRY_COUNT int = 10
+const LEADER_RETRY_INTERVAL = 200 * time.Millisecond
+
 type RPCClient struct {
-	MetaStoreAddr string
-	BaseDir       string
-	BlockSize     int
+	MetaStoreAddrs []string
+	BaseDir        string
+	BlockSize      int
+	Chunker        Chunker
+	Concurrency    int
+	ClientName     string
+	Compression    Compression
+	Cipher         *Cipher
+	DialOption     grpc.DialOption
+	Token          string
+	leader         *metaStoreLeader
+
+	// deadlines of calls by method name, with the default for unary calls
+	// under "" (see ParseTimeouts), and how failed idempotent calls
+	// (GetBlock, PutBlock, HasBlocks, GetFileInfoMap and GetChangesSince)
+	// are retried; they are tried once if Retry is nil
+	Timeouts map[string]time.Duration
+	Retry    *RetryPolicy
+
+	// connections reused across calls; each call dials its own if nil
+	conns *connPool
+	// paths sync passes wrote, recorded only for the daemon
+	writes *ownWrites
 }
 
-func (surfClient *RPCClient) GetBlockHashes(blockStoreAddr string, blockHashes *[]string) error {
-	conn, err := grpc.Dial(blockStoreAddr, grpc.WithInsecure())
-	if err != nil {
-		return err
+// Connection to addr, and the function to call once done with it
+func (surfClient *RPCClient) connect(addr string) (*grpc.ClientConn, func(), error) {
+	opts := dialOptions(surfClient.DialOption, surfClient.Token)
+	if surfClient.conns == nil {
+		conn, err := grpc.Dial(addr, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn, func() { conn.Close() }, nil
 	}
-	c := NewBlockStoreClient(conn)
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	hashes, err := c.GetBlockHashes(ctx, &emptypb.Empty{})
+	conn, err := surfClient.conns.get(addr, opts)
 	if err != nil {
-		conn.Close()
-		log.Println(err)
-		return err
+		return nil, nil, err
 	}
+	return conn, func() {}, nil
+}
 
-	*blockHashes = hashes.Hashes
+// Close the pooled connections of the client
+func (surfClient *RPCClient) Close() error {
+	if surfClient.conns == nil {
+		return nil
+	}
+	return surfClient.conns.close()
+}
 
-	return conn.Close()
+func (surfClient *RPCClient) GetBlockHashes(blockStoreAddr string, blockHashes *[]string) error {
+	return surfClient.callBlockStore("GetBlockHashes", blockStoreAddr, false, func(c BlockStoreClient, ctx context.Context) error {
+		hashes, err := c.GetBlockHashes(ctx, &emptypb.Empty{})
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*blockHashes = hashes.Hashes
+		return nil
+	})
 }
 
-func (surfClient *RPCClient) GetBlock(blockHash string, blockStoreAddr string, block *Block) error {
-	// connect to the server
-	conn, err := grpc.Dial(blockStoreAddr, grpc.WithInsecure())
+// ListBlockHashes gets the same list as GetBlockHashes over a stream of
+// pages, so it works for block servers holding any number of blocks
+func (surfClient *RPCClient) ListBlockHashes(blockStoreAddr string, blockHashes *[]string) error {
+	conn, release, err := surfClient.connect(blockStoreAddr)
 	if err != nil {
-		return err
+		return newRPCError("ListBlockHashes", blockStoreAddr, err)
 	}
+	defer release()
 	c := NewBlockStoreClient(conn)
 
-	// perform the call
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := surfClient.callContext(context.Background(), "ListBlockHashes", true)
 	defer cancel()
-	b, err := c.GetBlock(ctx, &BlockHash{Hash: blockHash})
+	stream, err := c.ListBlockHashes(ctx, &emptypb.Empty{})
 	if err != nil {
-		conn.Close()
-		return err
+		log.Println(err)
+		return newRPCError("ListBlockHashes", blockStoreAddr, err)
 	}
-	block.BlockData = b.BlockData
-	block.BlockSize = b.BlockSize
+	hashes := []string{}
+	for {
+		page, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Println(err)
+			return newRPCError("ListBlockHashes", blockStoreAddr, err)
+		}
+		hashes = append(hashes, page.Hashes...)
+	}
+	*blockHashes = hashes
+	return nil
+}
 
-	// close the connection
-	return conn.Close()
+func (surfClient *RPCClient) GetBlock(blockHash string, blockStoreAddr string, block *Block) error {
+	return surfClient.callBlockStore("GetBlock", blockStoreAddr, true, func(c BlockStoreClient, ctx context.Context) error {
+		b, err := c.GetBlock(withAcceptCompression(ctx), &BlockHash{Hash: blockHash})
+		if err != nil {
+			return err
+		}
+		block.BlockData = b.BlockData
+		block.BlockSize = b.BlockSize
+		block.Compression = b.Compression
+		return nil
+	})
 }
 
 func (surfClient *RPCClient) PutBlock(block *Block, blockStoreAddr string, succ *bool) error {
-	conn, err := grpc.Dial(blockStoreAddr, grpc.WithInsecure())
+	return surfClient.callBlockStore("PutBlock", blockStoreAddr, true, func(c BlockStoreClient, ctx context.Context) error {
+		s, err := c.PutBlock(ctx, block)
+		if err != nil {
+			return err
+		}
+		*succ = s.Flag
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) HasBlocks(blockHashesIn []string, blockStoreAddr string, blockHashesOut *[]string) error {
+	return surfClient.callBlockStore("HasBlocks", blockStoreAddr, true, func(c BlockStoreClient, ctx context.Context) error {
+		hashes, err := c.HasBlocks(ctx, &BlockHashes{Hashes: blockHashesIn})
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*blockHashesOut = hashes.Hashes
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) DeleteBlocks(blockHashesIn []string, gracePeriod time.Duration, blockStoreAddr string, blockHashesOut *[]string) error {
+	return surfClient.callBlockStore("DeleteBlocks", blockStoreAddr, false, func(c BlockStoreClient, ctx context.Context) error {
+		hashes, err := c.DeleteBlocks(ctx, &DeleteBlocksInput{Hashes: blockHashesIn, GracePeriodMs: gracePeriod.Milliseconds()})
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*blockHashesOut = hashes.Hashes
+		return nil
+	})
+}
+
+// callBlockStore runs a unary call of method against a block server, with
+// the deadline configured for it. Idempotent calls are retried according
+// to the client's RetryPolicy.
+func (surfClient *RPCClient) callBlockStore(method string, blockStoreAddr string, idempotent bool, call func(c BlockStoreClient, ctx context.Context) error) error {
+	attempt := func() error {
+		conn, release, err := surfClient.connect(blockStoreAddr)
+		if err != nil {
+			log.Println("Dial error: ", err)
+			return err
+		}
+		defer release()
+		ctx, cancel := surfClient.callContext(context.Background(), method, false)
+		defer cancel()
+		return call(NewBlockStoreClient(conn), ctx)
+	}
+	var err error
+	if idempotent {
+		err = surfClient.Retry.run(attempt)
+	} else {
+		err = attempt()
+	}
+	return newRPCError(method, blockStoreAddr, err)
+}
+
+// PutBlocks sends blocks to a block server over a single stream. Streams
+// carry any number of blocks, so unlike the unary calls they get no overall
+// deadline unless one is configured for them, and are not retried: callers
+// send the blocks of a failed stream again with PutBlock, which is.
+func (surfClient *RPCClient) PutBlocks(blocks []*Block, blockStoreAddr string, succ *bool) error {
+	conn, release, err := surfClient.connect(blockStoreAddr)
 	if err != nil {
 		log.Println("Dial error: ", err)
-		return err
+		return newRPCError("PutBlocks", blockStoreAddr, err)
 	}
+	defer release()
 	c := NewBlockStoreClient(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := surfClient.callContext(context.Background(), "PutBlocks", true)
 	defer cancel()
-	s, err := c.PutBlock(ctx, block)
-	if err != nil || !s.Flag {
-		conn.Close()
-		return err
+	stream, err := c.PutBlocks(ctx)
+	if err != nil {
+		log.Println(err)
+		return newRPCError("PutBlocks", blockStoreAddr, err)
+	}
+	for _, block := range blocks {
+		if err := stream.Send(block); err != nil {
+			// the real error is reported by CloseAndRecv
+			break
+		}
+	}
+	s, err := stream.CloseAndRecv()
+	if err != nil {
+		log.Println(err)
+		return newRPCError("PutBlocks", blockStoreAddr, err)
 	}
 	*succ = s.Flag
-
-	return conn.Close()
+	return nil
 }
 
-func (surfClient *RPCClient) HasBlocks(blockHashesIn []string, blockStoreAddr string, blockHashesOut *[]string) error {
-	conn, err := grpc.Dial(blockStoreAddr, grpc.WithInsecure())
+// GetBlocks fetches blocks from a block server over a single stream, in the
+// order of blockHashesIn.
+func (surfClient *RPCClient) GetBlocks(blockHashesIn []string, blockStoreAddr string, blocks *[]*Block) error {
+	conn, release, err := surfClient.connect(blockStoreAddr)
 	if err != nil {
-		log.Println(err)
-		return err
+		return newRPCError("GetBlocks", blockStoreAddr, err)
 	}
+	defer release()
 	c := NewBlockStoreClient(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := surfClient.callContext(withAcceptCompression(context.Background()), "GetBlocks", true)
 	defer cancel()
-	hashes, err := c.HasBlocks(ctx, &BlockHashes{Hashes: blockHashesIn})
+	stream, err := c.GetBlocks(ctx, &BlockHashes{Hashes: blockHashesIn})
 	if err != nil {
-		conn.Close()
 		log.Println(err)
-		return err
+		return newRPCError("GetBlocks", blockStoreAddr, err)
 	}
-
-	*blockHashesOut = hashes.Hashes
-
-	return conn.Close()
+	received := make([]*Block, 0, len(blockHashesIn))
+	for {
+		block, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Println(err)
+			return newRPCError("GetBlocks", blockStoreAddr, err)
+		}
+		received = append(received, block)
+	}
+	if len(received) != len(blockHashesIn) {
+		return newRPCError("GetBlocks", blockStoreAddr, fmt.Errorf("received %v of %v blocks from %v", len(received), len(blockHashesIn), blockStoreAddr))
+	}
+	*blocks = received
+	return nil
 }
 
-func (surfClient *RPCClient) GetFileInfoMap(serverFileInfoMap *map[string]*FileMetaData) error {
-	conn, err := grpc.Dial(surfClient.MetaStoreAddr, grpc.WithInsecure())
+// GetBlocksStream fetches blocks like GetBlocks, but over a bidirectional
+// stream: hashes are sent while blocks are already coming back.
+func (surfClient *RPCClient) GetBlocksStream(blockHashesIn []string, blockStoreAddr string, blocks *[]*Block) error {
+	conn, release, err := surfClient.connect(blockStoreAddr)
 	if err != nil {
-		log.Println(err)
-		return err
+		return newRPCError("GetBlocksStream", blockStoreAddr, err)
 	}
-	c := NewMetaStoreClient(conn)
+	defer release()
+	c := NewBlockStoreClient(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := surfClient.callContext(withAcceptCompression(context.Background()), "GetBlocksStream", true)
 	defer cancel()
-	mp, err := c.GetFileInfoMap(ctx, &emptypb.Empty{})
+	stream, err := c.GetBlocksStream(ctx)
 	if err != nil {
-		conn.Close()
 		log.Println(err)
-		return err
+		return newRPCError("GetBlocksStream", blockStoreAddr, err)
 	}
+	go func() {
+		for _, hash := range blockHashesIn {
+			if err := stream.Send(&BlockHash{Hash: hash}); err != nil {
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+	received := make([]*Block, 0, len(blockHashesIn))
+	for len(received) < len(blockHashesIn) {
+		block, err := stream.Recv()
+		if err == io.EOF {
+			return newRPCError("GetBlocksStream", blockStoreAddr, fmt.Errorf("received %v of %v blocks from %v", len(received), len(blockHashesIn), blockStoreAddr))
+		} else if err != nil {
+			log.Println(err)
+			return newRPCError("GetBlocksStream", blockStoreAddr, err)
+		}
+		received = append(received, block)
+	}
+	*blocks = received
+	return nil
+}
 
-	*serverFileInfoMap = mp.FileInfoMap
-
-	return conn.Close()
+func (surfClient *RPCClient) GetFileInfoMap(serverFileInfoMap *map[string]*FileMetaData) error {
+	return surfClient.callMetaStore("GetFileInfoMap", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		mp, err := c.GetFileInfoMap(ctx, &emptypb.Empty{}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*serverFileInfoMap = surfClient.decryptFileInfoMap(mp.FileInfoMap)
+		return nil
+	})
 }
 
 func (surfClient *RPCClient) UpdateFile(fileMetaData *FileMetaData, latestVersion *int32) error {
-	conn, err := grpc.Dial(surfClient.MetaStoreAddr, grpc.WithInsecure())
-	if err != nil {
-		log.Println(err)
-		return err
+	if surfClient.Cipher != nil {
+		fileMetaData = &FileMetaData{
+			Filename:      surfClient.encryptName(fileMetaData.Filename),
+			Version:       fileMetaData.Version,
+			BlockHashList: fileMetaData.BlockHashList,
+		}
 	}
-	c := NewMetaStoreClient(conn)
+	return surfClient.callMetaStore("UpdateFile", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		v, err := c.UpdateFile(ctx, fileMetaData, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*latestVersion = v.Version
+		return nil
+	})
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	v, err := c.UpdateFile(ctx, fileMetaData)
-	if err != nil {
-		conn.Close()
-		log.Println(err)
-		return err
+func (surfClient *RPCClient) GetBlockStoreMap(blockHashesIn []string, blockStoreMap *map[string][]string) error {
+	return surfClient.callMetaStore("GetBlockStoreMap", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		blockStoreMapFormatted, err := c.GetBlockStoreMap(ctx, &BlockHashes{Hashes: blockHashesIn}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		for key, value := range blockStoreMapFormatted.BlockStoreMap {
+			(*blockStoreMap)[key] = value.Hashes
+		}
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) GetBlockStoreAddrs(blockStoreAddrs *[]string) error {
+	return surfClient.callMetaStore("GetBlockStoreAddrs", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		addr, err := c.GetBlockStoreAddrs(ctx, &emptypb.Empty{}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*blockStoreAddrs = addr.BlockStoreAddrs
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) AddBlockStore(blockStoreAddr string, weight int, succ *bool) error {
+	return surfClient.callMetaStore("AddBlockStore", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		success, err := c.AddBlockStore(ctx, &BlockStoreAddr{Addr: blockStoreAddr, Weight: int32(weight)}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*succ = success.Flag
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) RemoveBlockStore(blockStoreAddr string, succ *bool) error {
+	return surfClient.callMetaStore("RemoveBlockStore", false, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		success, err := c.RemoveBlockStore(ctx, &BlockStoreAddr{Addr: blockStoreAddr}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*succ = success.Flag
+		return nil
+	})
+}
+
+// Grant grantee access to a folder. With encryption the grantee needs the
+// same passphrase to read it.
+func (surfClient *RPCClient) ShareFolder(path string, grantee string, permission Permission, succ *bool) error {
+	if surfClient.Cipher != nil {
+		path = surfClient.Cipher.EncryptFilename(path)
 	}
+	return surfClient.callMetaStore("ShareFolder", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		success, err := c.ShareFolder(ctx, &Share{Path: path, Grantee: grantee, Permission: permission}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*succ = success.Flag
+		return nil
+	})
+}
 
-	*latestVersion = v.Version
+func (surfClient *RPCClient) UnshareFolder(path string, grantee string, succ *bool) error {
+	if surfClient.Cipher != nil {
+		path = surfClient.Cipher.EncryptFilename(path)
+	}
+	return surfClient.callMetaStore("UnshareFolder", false, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		success, err := c.UnshareFolder(ctx, &Share{Path: path, Grantee: grantee}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*succ = success.Flag
+		return nil
+	})
+}
 
-	return conn.Close()
+func (surfClient *RPCClient) GetShares(shares *[]*Share) error {
+	return surfClient.callMetaStore("GetShares", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		out, err := c.GetShares(ctx, &emptypb.Empty{}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*shares = out.Shares
+		if surfClient.Cipher != nil {
+			for _, share := range *shares {
+				if path, err := surfClient.Cipher.DecryptFilename(share.Path); err == nil {
+					share.Path = path
+				}
+			}
+		}
+		return nil
+	})
 }
 
-func (surfClient *RPCClient) GetBlockStoreMap(blockHashesIn []string, blockStoreMap *map[string][]string) error {
-	// todo: implement
-	conn, err := grpc.Dial(surfClient.MetaStoreAddr, grpc.WithInsecure())
-	if err != nil {
-		log.Println(err)
-		return conn.Close()
+// GetChangesSince fetches a page of at most limit files changed after the
+// cursor of the given epoch (see MetaStore.GetChangesSince). Changes to
+// files whose name cannot be decrypted are left out.
+func (surfClient *RPCClient) GetChangesSince(epoch string, cursor int64, limit int, changes *Changes) error {
+	return surfClient.callMetaStore("GetChangesSince", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		out, err := c.GetChangesSince(ctx, &ChangesRequest{Epoch: epoch, Cursor: cursor, Limit: int32(limit)}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		changes.Changes = surfClient.decryptFiles(out.Changes)
+		changes.Cursor = out.Cursor
+		changes.Epoch = out.Epoch
+		changes.HasMore = out.HasMore
+		changes.Resync = out.Resync
+		return nil
+	})
+}
+
+// GetFileVersions fetches every version the MetaStore keeps of the file at
+// path, or of the files below it, ordered by filename and oldest first
+// (see MetaStore.GetFileVersions). Files whose name cannot be decrypted are
+// left out.
+func (surfClient *RPCClient) GetFileVersions(path string, versions *[]*FileMetaData) error {
+	if surfClient.Cipher != nil && path != "" {
+		path = surfClient.encryptName(path)
 	}
-	c := NewMetaStoreClient(conn)
+	return surfClient.callMetaStore("GetFileVersions", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		out, err := c.GetFileVersions(ctx, &VersionsRequest{Path: path}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*versions = surfClient.decryptFiles(out.Versions)
+		return nil
+	})
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	blockHashesInFormatted := BlockHashes{Hashes: blockHashesIn}
-	blockStoreMapFormatted, err := c.GetBlockStoreMap(ctx, &blockHashesInFormatted)
-	if err != nil {
-		log.Println(err)
-		return conn.Close()
+func (surfClient *RPCClient) GetFileVersion(filename string, version int32, fileMetaData *FileMetaData) error {
+	name := filename
+	if surfClient.Cipher != nil {
+		name = surfClient.encryptName(filename)
 	}
-	log.Println("blockStoreMapFormatted: ", blockStoreMapFormatted)
-	for key, value := range blockStoreMapFormatted.BlockStoreMap {
-		log.Println("key: ", key)
-		log.Println("value: ", value)
-		log.Println("blockStoreMap: ", blockStoreMap)
-		(*blockStoreMap)[key] = value.Hashes
+	return surfClient.callMetaStore("GetFileVersion", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		out, err := c.GetFileVersion(ctx, &VersionRequest{Filename: name, Version: version}, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		fileMetaData.Filename = filename
+		fileMetaData.Version = out.Version
+		fileMetaData.BlockHashList = out.BlockHashList
+		fileMetaData.CommittedAt = out.CommittedAt
+		return nil
+	})
+}
+
+// GetTrash fetches the deleted files in the trash at path, or below it (see
+// MetaStore.GetTrash). Files whose name cannot be decrypted are left out.
+func (surfClient *RPCClient) GetTrash(path string, files *[]*TrashedFile) error {
+	request := surfClient.trashRequest(path)
+	return surfClient.callMetaStore("GetTrash", true, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		out, err := c.GetTrash(ctx, request, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*files = surfClient.decryptTrash(out.Files)
+		return nil
+	})
+}
+
+// Undelete brings back the deleted file at path, or the files deleted below
+// it, returning them in their new version (see MetaStore.Undelete)
+func (surfClient *RPCClient) Undelete(path string, files *[]*TrashedFile) error {
+	request := surfClient.trashRequest(path)
+	return surfClient.callMetaStore("Undelete", false, func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error {
+		out, err := c.Undelete(ctx, request, opt)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		*files = surfClient.decryptTrash(out.Files)
+		return nil
+	})
+}
+
+func (surfClient *RPCClient) trashRequest(path string) *TrashRequest {
+	if surfClient.Cipher != nil && path != "" {
+		path = surfClient.encryptName(path)
 	}
-	return conn.Close()
+	return &TrashRequest{Path: path}
 }
 
-func (surfClient *RPCClient) GetBlockStoreAddrs(blockStoreAddrs *[]string) error {
-	// todo: implement
-	conn, err := grpc.Dial(surfClient.MetaStoreAddr, grpc.WithInsecure())
+func (surfClient *RPCClient) decryptTrash(files []*TrashedFile) []*TrashedFile {
+	if surfClient.Cipher == nil {
+		return files
+	}
+	decrypted := []*TrashedFile{}
+	for _, trashed := range files {
+		if surfClient.decryptFilename(trashed.FileMetaData) {
+			decrypted = append(decrypted, trashed)
+		}
+	}
+	return decrypted
+}
+
+// WatchChanges passes every change to the caller's files committed after
+// sequence number since of the given epoch to handle, as it happens, until
+// ctx is done or handle fails. A stream that breaks, such as when the Raft
+// leader changes, is resumed from the last change handled; once it has
+// delivered a change, finding the leader and retrying start over, so a
+// long-lived stream never runs out of retries. An event without a file
+// marks that the changes made before the call were all passed, or, with
+// Resync set, that they are no longer known and all files have to be
+// listed.
+func (surfClient *RPCClient) WatchChanges(ctx context.Context, epoch string, since int64, handle func(change *FileChange) error) error {
+	var handleErr error
+	for {
+		received := false
+		err := surfClient.callMetaStore("WatchChanges", true, func(c MetaStoreClient, _ context.Context, opt grpc.CallOption) error {
+			// the stream outlives the deadline of unary calls
+			watchCtx, cancel := surfClient.callContext(ctx, "WatchChanges", true)
+			defer cancel()
+			stream, err := c.WatchChanges(watchCtx, &WatchRequest{Epoch: epoch, Since: since}, opt)
+			if err != nil {
+				return err
+			}
+			for {
+				change, err := stream.Recv()
+				if err != nil && received {
+					log.Println("Change stream broke, resuming: ", err)
+					return nil
+				} else if err != nil {
+					return err
+				}
+				received = true
+				if change.FileMetaData != nil && surfClient.Cipher != nil && !surfClient.decryptFilename(change.FileMetaData) {
+					since = change.Sequence
+					continue
+				}
+				if handleErr = handle(change); handleErr != nil {
+					return handleErr
+				}
+				if change.FileMetaData == nil {
+					epoch = change.Epoch
+				}
+				since = change.Sequence
+			}
+		})
+		if handleErr != nil {
+			return handleErr
+		} else if ctx.Err() != nil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// callMetaStore runs call against the MetaStore leader. Servers that are
+// unreachable or not the leader are skipped, following the leader hint a
+// follower returns, and the leader found is remembered for later calls.
+// While a Raft cluster is electing a leader the addresses are retried a few
+// times, and while no server can be reached, idempotent calls are retried
+// as the client's RetryPolicy says. A call that is not idempotent is only sent on to another server,
+// or again, after a follower refused it: once it timed out or the
+// connection broke, it may have reached the leader and is not repeated.
+// UpdateFile counts as idempotent, since the MetaStore accepts an update it
+// already applied again. The error returned is a TransientError or a
+// PermanentError.
+func (surfClient *RPCClient) callMetaStore(method string, idempotent bool, call func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error) error {
+	var lastErr error
+	lastAddr := ""
+	retries := 0
+	for attempt := 0; attempt < LEADER_RETRY_COUNT; attempt++ {
+		candidates := append([]string{surfClient.leader.get()}, surfClient.MetaStoreAddrs...)
+		tried := map[string]bool{"": true}
+		notLeader := false
+		for len(candidates) > 0 {
+			addr := candidates[0]
+			candidates = candidates[1:]
+			if tried[addr] {
+				continue
+			}
+			tried[addr] = true
+
+			var header metadata.MD
+			err := surfClient.callMetaStoreAt(method, addr, call, grpc.Header(&header))
+			if err == nil {
+				surfClient.leader.set(addr)
+				return nil
+			}
+			lastErr, lastAddr = err, addr
+			switch status.Code(err) {
+			case codes.FailedPrecondition:
+				notLeader = true
+				if hint := header.Get(LEADER_HEADER); len(hint) > 0 {
+					candidates = append([]string{hint[0]}, candidates...)
+				}
+			case codes.Unavailable, codes.DeadlineExceeded:
+				if !idempotent {
+					return newRPCError(method, addr, err)
+				}
+			default:
+				return newRPCError(method, addr, err)
+			}
+		}
+		if notLeader {
+			// let the election finish
+			time.Sleep(LEADER_RETRY_INTERVAL)
+			continue
+		}
+		retries++
+		if surfClient.Retry == nil || retries >= surfClient.Retry.MaxAttempts {
+			break
+		}
+		backoff := surfClient.Retry.backoff(retries)
+		log.Printf("Retrying in %v after: %v\n", backoff, lastErr)
+		time.Sleep(backoff)
+	}
+	return newRPCError(method, lastAddr, lastErr)
+}
+
+func (surfClient *RPCClient) callMetaStoreAt(method string, addr string, call func(c MetaStoreClient, ctx context.Context, opt grpc.CallOption) error, opt grpc.CallOption) error {
+	conn, release, err := surfClient.connect(addr)
 	if err != nil {
 		log.Println(err)
-		return conn.Close()
+		return err
 	}
+	defer release()
 	c := NewMetaStoreClient(conn)
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := surfClient.callContext(context.Background(), method, false)
 	defer cancel()
-	addr, err := c.GetBlockStoreAddrs(ctx, &emptypb.Empty{})
-	if err != nil {
-		conn.Close()
-		log.Println(err)
-		return err
+	return call(c, ctx, opt)
+}
+
+// metaStoreLeader remembers the MetaStore server that last answered. It is
+// shared by all copies of an RPCClient.
+type metaStoreLeader struct {
+	mtx  sync.Mutex
+	addr string
+}
+
+func (l *metaStoreLeader) get() string {
+	if l == nil {
+		return ""
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.addr
+}
+
+func (l *metaStoreLeader) set(addr string) {
+	if l == nil {
+		return
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.addr = addr
+}
+
+// Key a FileInfoMap by plaintext filenames. Entries whose name cannot be
+// decrypted, written without encryption or with another passphrase, are
+// left out.
+func (surfClient *RPCClient) decryptFileInfoMap(fileInfoMap map[string]*FileMetaData) map[string]*FileMetaData {
+	if surfClient.Cipher == nil {
+		return fileInfoMap
+	}
+	decrypted := make(map[string]*FileMetaData, len(fileInfoMap))
+	for encryptedName, fileMetaData := range fileInfoMap {
+		fileMetaData = &FileMetaData{
+			Filename:      encryptedName,
+			Version:       fileMetaData.Version,
+			BlockHashList: fileMetaData.BlockHashList,
+		}
+		if surfClient.decryptFilename(fileMetaData) {
+			decrypted[fileMetaData.Filename] = fileMetaData
+		}
 	}
+	return decrypted
+}
 
-	*blockStoreAddrs = addr.BlockStoreAddrs
+// Encrypt a filename. In folders shared with us, SHARED_DIR and the owner
+// stay readable and the rest is encrypted as the owner did.
+func (surfClient *RPCClient) encryptName(filename string) string {
+	if owner, path, ok := splitSharedName(filename); ok {
+		return SHARED_DIR + "/" + owner + "/" + surfClient.Cipher.EncryptFilename(path)
+	} else if isSharedName(filename) {
+		return filename
+	}
+	return surfClient.Cipher.EncryptFilename(filename)
+}
 
-	return conn.Close()
+// Decrypt the names of files in place. Files whose name cannot be
+// decrypted are left out.
+func (surfClient *RPCClient) decryptFiles(files []*FileMetaData) []*FileMetaData {
+	if surfClient.Cipher == nil {
+		return files
+	}
+	decrypted := []*FileMetaData{}
+	for _, fileMetaData := range files {
+		if surfClient.decryptFilename(fileMetaData) {
+			decrypted = append(decrypted, fileMetaData)
+		}
+	}
+	return decrypted
+}
+
+// Decrypt the name of fileMetaData in place. Returns false, leaving it
+// unchanged, if the name cannot be decrypted, as it was written without
+// encryption or with another passphrase.
+func (surfClient *RPCClient) decryptFilename(fileMetaData *FileMetaData) bool {
+	filename, err := surfClient.decryptName(fileMetaData.Filename)
+	if err != nil {
+		log.Println("Skipping file with undecryptable name: ", fileMetaData.Filename)
+		return false
+	}
+	fileMetaData.Filename = filename
+	return true
+}
+
+func (surfClient *RPCClient) decryptName(filename string) (string, error) {
+	if owner, path, ok := splitSharedName(filename); ok {
+		path, err := surfClient.Cipher.DecryptFilename(path)
+		return SHARED_DIR + "/" + owner + "/" + path, err
+	} else if isSharedName(filename) {
+		return filename, nil
+	}
+	return surfClient.Cipher.DecryptFilename(filename)
 }
 
 // This line guarantees all method for RPCClient are implemented
 var _ ClientInterface = new(RPCClient)
 
-// Create an Surfstore RPC client
+// Create an Surfstore RPC client. hostPort is a MetaStore address, or a
+// comma separated list of the servers of a Raft MetaStore cluster.
 func NewSurfstoreRPCClient(hostPort, baseDir string, blockSize int) RPCClient {
+	// names this client in conflict copies
+	clientName, err := os.Hostname()
+	if err != nil {
+		clientName = "unknown"
+	}
 
 	return RPCClient{
-		MetaStoreAddr: hostPort,
-		BaseDir:       baseDir,
-		BlockSize:     blockSize,
+		MetaStoreAddrs: strings.Split(hostPort, CONFIG_DELIMITER),
+		BaseDir:        baseDir,
+		BlockSize:      blockSize,
+		Chunker:        &FixedChunker{BlockSize: blockSize},
+		Concurrency:    DEFAULT_CONCURRENCY,
+		ClientName:     clientName,
+		leader:         &metaStoreLeader{},
+		Timeouts:       map[string]time.Duration{},
+		Retry:          DefaultRetryPolicy(),
+		conns:          newConnPool(),
 	}
 }
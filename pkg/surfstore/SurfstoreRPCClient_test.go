@@ -0,0 +1,87 @@
+package surfstore
+
+import (
+	context "context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// A MetaStore whose change streams break after every event
+type breakingWatchMetaStore struct {
+	*MetaStore
+}
+
+func (m breakingWatchMetaStore) WatchChanges(request *WatchRequest, stream MetaStore_WatchChangesServer) error {
+	if err := stream.Send(&FileChange{Sequence: request.Since + 1, Epoch: request.Epoch}); err != nil {
+		return err
+	}
+	return status.Error(codes.Unavailable, "stream broke")
+}
+
+func TestWatchChangesResumesBrokenStreams(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	RegisterMetaStoreServer(server, breakingWatchMetaStore{NewMetaStore([]string{})})
+	go server.Serve(l)
+	defer server.Stop()
+
+	client := NewSurfstoreRPCClient(l.Addr().String(), t.TempDir(), 1024)
+	defer client.Close()
+	client.Retry = &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 1}
+
+	// far more breaks than the client retries a failing call
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := 0
+	err = client.WatchChanges(ctx, "e", 0, func(change *FileChange) error {
+		events++
+		if change.Sequence != int64(events) {
+			t.Errorf("event %v has sequence %v", events, change.Sequence)
+		}
+		if events == 3*LEADER_RETRY_COUNT {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil || events != 3*LEADER_RETRY_COUNT {
+		t.Errorf("stream ended after %v events: %v", events, err)
+	}
+}
+
+func TestListBlockHashesPastMessageLimit(t *testing.T) {
+	blockStore := NewBlockStore()
+	// more hashes than fit in the largest message a client receives
+	count := BLOCK_MAX_MSG_SIZE/len(GetBlockHashString(nil)) + BLOCK_HASHES_PAGE_SIZE
+	for i := 0; i < count; i++ {
+		data := []byte(fmt.Sprint(i))
+		if err := blockStore.Backend.Put(GetBlockHashString(data), &Block{BlockData: data, BlockSize: int32(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := newTestClient(t, startTestServer(t, blockStore), "a")
+	addr := client.MetaStoreAddrs[0]
+
+	var hashes []string
+	if err := client.GetBlockHashes(addr, &hashes); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("GetBlockHashes of %v blocks: %v", count, err)
+	}
+	if err := client.ListBlockHashes(addr, &hashes); err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, hash := range hashes {
+		seen[hash] = true
+	}
+	if len(hashes) != count || len(seen) != count {
+		t.Errorf("listed %v hashes, %v distinct, expected %v", len(hashes), len(seen), count)
+	}
+}
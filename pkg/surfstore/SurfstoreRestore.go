@@ -0,0 +1,100 @@
+package surfstore
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// RestoreVersion makes a kept version of filename its current one again,
+// committed as a new version on top of the current one, so clients pick it
+// up on their next sync. Returns false if it already is the current one.
+func RestoreVersion(client RPCClient, filename string, version int32) (bool, error) {
+	var target FileMetaData
+	if err := client.GetFileVersion(filename, version, &target); err != nil {
+		return false, err
+	}
+	var versions []*FileMetaData
+	if err := client.GetFileVersions(filename, &versions); err != nil {
+		return false, err
+	}
+	var current *FileMetaData
+	for _, fileMetaData := range versions {
+		if fileMetaData.Filename == filename {
+			current = fileMetaData
+		}
+	}
+	if current == nil {
+		return false, status.Errorf(codes.NotFound, "%v not found", filename)
+	}
+	if reflect.DeepEqual(current.BlockHashList, target.BlockHashList) {
+		return false, nil
+	}
+	return true, restoreHashes(client, current, target.BlockHashList)
+}
+
+// RestoreAsOf restores the file at path, or every file below it, to the
+// version that was current at time at; files created since are deleted.
+// Returns the files changed, and the files whose version at that time is
+// no longer kept, which are left alone. An empty path restores all files.
+func RestoreAsOf(client RPCClient, path string, at time.Time) (restored []string, unknown []string, err error) {
+	var versions []*FileMetaData
+	if err := client.GetFileVersions(path, &versions); err != nil {
+		return nil, nil, err
+	}
+	// versions are ordered by filename, oldest first
+	for start := 0; start < len(versions); {
+		end := start
+		for end < len(versions) && versions[end].Filename == versions[start].Filename {
+			end++
+		}
+		fileVersions := versions[start:end]
+		start = end
+
+		filename := fileVersions[0].Filename
+		current := fileVersions[len(fileVersions)-1]
+		var target []string
+		for _, fileMetaData := range fileVersions {
+			if fileMetaData.CommittedAt <= at.UnixMilli() {
+				target = fileMetaData.BlockHashList
+			}
+		}
+		if target == nil {
+			// the first version postdates at, unless earlier ones were dropped
+			if fileVersions[0].Version != 1 {
+				unknown = append(unknown, filename)
+				continue
+			}
+			target = []string{TOMBSTONE_HASHVALUE}
+		}
+		if reflect.DeepEqual(current.BlockHashList, target) {
+			continue
+		}
+		if err := restoreHashes(client, current, target); err != nil {
+			return restored, unknown, err
+		}
+		restored = append(restored, filename)
+	}
+	return restored, unknown, nil
+}
+
+// Commit hashes as the version after current. The blocks of kept versions
+// are never garbage collected, so they need not be uploaded again.
+func restoreHashes(client RPCClient, current *FileMetaData, hashes []string) error {
+	var latestVersion int32
+	fileMetaData := &FileMetaData{
+		Filename:      current.Filename,
+		Version:       current.Version + 1,
+		BlockHashList: hashes,
+	}
+	if err := client.UpdateFile(fileMetaData, &latestVersion); err != nil {
+		return err
+	}
+	if latestVersion == -1 {
+		return fmt.Errorf("%v changed while restoring it, try again", current.Filename)
+	}
+	return nil
+}
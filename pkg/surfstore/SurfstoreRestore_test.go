@@ -0,0 +1,64 @@
+package surfstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestoreAsOf(t *testing.T) {
+	addr := startTestServer(t, NewBlockStore())
+	client := newTestClient(t, addr, "a")
+	committedAt := map[string]int64{}
+	for _, update := range []struct {
+		filename string
+		version  int32
+		hash     string
+	}{{"f", 1, "h1"}, {"f", 2, "h2"}, {"new", 1, "n1"}, {"f", 3, "h3"}} {
+		// versions are committed at distinct milliseconds
+		time.Sleep(5 * time.Millisecond)
+		var latestVersion int32
+		if err := client.UpdateFile(&FileMetaData{Filename: update.filename, Version: update.version, BlockHashList: []string{update.hash}}, &latestVersion); err != nil || latestVersion != update.version {
+			t.Fatalf("update of %v: %v, %v", update.filename, latestVersion, err)
+		}
+		var fileMetaData FileMetaData
+		if err := client.GetFileVersion(update.filename, update.version, &fileMetaData); err != nil {
+			t.Fatal(err)
+		}
+		committedAt[update.hash] = fileMetaData.CommittedAt
+	}
+
+	tests := []struct {
+		name     string
+		at       int64
+		restored []string
+		hashes   map[string]string
+	}{
+		{"at a commit", committedAt["h2"], []string{"f", "new"}, map[string]string{"f": "h2", "new": TOMBSTONE_HASHVALUE}},
+		{"before a commit", committedAt["h2"] - 1, []string{"f"}, map[string]string{"f": "h1", "new": TOMBSTONE_HASHVALUE}},
+		{"before the first version", committedAt["h1"] - 1, []string{"f"}, map[string]string{"f": TOMBSTONE_HASHVALUE, "new": TOMBSTONE_HASHVALUE}},
+	}
+	for _, test := range tests {
+		restored, unknown, err := RestoreAsOf(client, "", time.UnixMilli(test.at))
+		if err != nil || len(unknown) != 0 {
+			t.Fatalf("%v: %v, unknown %v", test.name, err, unknown)
+		}
+		if len(restored) != len(test.restored) {
+			t.Errorf("%v: restored %v, expected %v", test.name, restored, test.restored)
+		}
+		for i := range restored {
+			if i < len(test.restored) && restored[i] != test.restored[i] {
+				t.Errorf("%v: restored %v, expected %v", test.name, restored, test.restored)
+				break
+			}
+		}
+		fileInfoMap := map[string]*FileMetaData{}
+		if err := client.GetFileInfoMap(&fileInfoMap); err != nil {
+			t.Fatal(err)
+		}
+		for filename, hash := range test.hashes {
+			if hashes := fileInfoMap[filename].BlockHashList; len(hashes) != 1 || hashes[0] != hash {
+				t.Errorf("%v: %v has hashes %v, expected %v", test.name, filename, hashes, hash)
+			}
+		}
+	}
+}
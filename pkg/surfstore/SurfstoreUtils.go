@@ -1,209 +1,809 @@
 package surfstore
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"io/ioutil"
 	"log"
-	"math"
 	"os"
+	"path"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
+	sync "sync"
+	"time"
+
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 )
 
+// Returned by uploadFile when the MetaStore rejects an update because the
+// file changed on the server since it was last synced
+var errUpdateRejected = errors.New("update rejected, file changed on the server")
+
+// Returned by uploadFile when the file no longer holds the blocks it was
+// hashed to, as it changed since
+var errFileChanged = errors.New("file changed since it was hashed")
+
 // Implement the logic for a client syncing with the server here.
 func ClientSync(client RPCClient) {
-	files, err := ioutil.ReadDir(client.BaseDir)
-	if err != nil {
+	if _, _, err := clientSync(client, nil); err != nil {
 		log.Println(err)
-		return
+	}
+}
+
+// One sync pass. If changed is set, only the files it lists, or that lie
+// below a directory it lists, are hashed again; the others keep the hashes
+// in the index. Returns the epoch and change cursor of the MetaStore the
+// pass synced to.
+func clientSync(client RPCClient, changed map[string]bool) (string, int64, error) {
+	files, err := walkBaseDir(client.BaseDir)
+	if err != nil {
+		return "", 0, err
 	}
 
 	localIndex, err := LoadMetaFromMetaFile(client.BaseDir)
 	if err != nil {
-		log.Println(err)
-		return
+		return "", 0, err
 	}
+	metaStore := strings.Join(client.MetaStoreAddrs, CONFIG_DELIMITER)
+	epoch, cursor, err := LoadCursor(client.BaseDir, metaStore)
+	if err != nil {
+		return "", 0, err
+	}
+	// after a sync the index matches the server's files, since the entries
+	// the server did not take are reset to the server's or left out
+	lastSynced := make(map[string]*FileMetaData, len(localIndex))
+	for filename, fileMetaData := range localIndex {
+		lastSynced[filename] = &FileMetaData{}
+		copyFileMetaData(lastSynced[filename], fileMetaData)
+	}
+
+	// files are processed concurrently, each sending its blocks through the
+	// shared block pool, so at most Concurrency transfers are in flight
+	filePool := newWorkerPool(client.Concurrency)
+	blockPool := newWorkerPool(client.Concurrency)
 
-	hashMap, err := syncLocalIndex(client, &localIndex, files)
+	hashMap, err := syncLocalIndex(client, filePool, &localIndex, files, changed)
 	if err != nil {
-		log.Println(err)
-		return
+		return "", 0, err
 	}
 
 	if err = checkDeletedFiles(&localIndex, hashMap); err != nil {
-		log.Println(err)
-		return
+		return "", 0, err
 	}
 
-	remoteIndex := make(map[string]*FileMetaData)
-	if err := client.GetFileInfoMap(&remoteIndex); err != nil {
-		log.Println(err)
-		return
+	remoteIndex, epoch, cursor, err := getRemoteIndex(client, lastSynced, epoch, cursor)
+	if err != nil {
+		return "", 0, err
 	}
+	removeUnshared(client, localIndex, lastSynced, remoteIndex)
 
-	var blockStoreAddrs []string
-	if err := client.GetBlockStoreAddrs(&blockStoreAddrs); err != nil {
-		log.Println(err)
-		return
+	if err = uploadNewFiles(client, filePool, blockPool, &localIndex, &remoteIndex, lastSynced); err != nil {
+		return "", 0, err
 	}
-	log.Println("blockStoreAddrs: ", blockStoreAddrs)
 
-	if err = uploadNewFiles(client, &localIndex, &remoteIndex, blockStoreAddrs); err != nil {
-		log.Println(err)
-		return
+	if err = downloadNewFiles(client, filePool, blockPool, &localIndex, &remoteIndex); err != nil {
+		return "", 0, err
+	}
+	if err := WriteMetaFile(localIndex, client.BaseDir); err != nil {
+		return "", 0, err
 	}
+	return epoch, cursor, WriteCursor(client.BaseDir, metaStore, epoch, cursor)
+}
 
-	if err = downloadNewFiles(client, &localIndex, &remoteIndex, blockStoreAddrs); err != nil {
-		log.Println(err)
-		return
+// The MetaStore's files, and the epoch and change cursor they are current
+// to. Only the files changed since the cursor of the last sync are fetched
+// and applied to lastSynced, unless the MetaStore asks for a full listing,
+// as it does when there is no cursor yet or it is of another epoch.
+func getRemoteIndex(client RPCClient, lastSynced map[string]*FileMetaData, epoch string, cursor int64) (map[string]*FileMetaData, string, int64, error) {
+	// lastSynced itself is left as it was, to tell local changes apart
+	remoteIndex := make(map[string]*FileMetaData, len(lastSynced))
+	for filename, fileMetaData := range lastSynced {
+		remoteIndex[filename] = fileMetaData
+	}
+	for {
+		var changes Changes
+		err := client.GetChangesSince(epoch, cursor, MAX_CHANGES_PAGE_SIZE, &changes)
+		if status.Code(err) == codes.Unimplemented {
+			// a MetaStore without change cursors
+			changes = Changes{Resync: true}
+		} else if err != nil {
+			return nil, "", 0, err
+		}
+		if changes.Resync {
+			remoteIndex = make(map[string]*FileMetaData)
+			if err := client.GetFileInfoMap(&remoteIndex); err != nil {
+				return nil, "", 0, err
+			}
+			return remoteIndex, changes.Epoch, changes.Cursor, nil
+		}
+		for _, fileMetaData := range changes.Changes {
+			remoteIndex[fileMetaData.Filename] = fileMetaData
+		}
+		epoch, cursor = changes.Epoch, changes.Cursor
+		if !changes.HasMore {
+			return remoteIndex, epoch, cursor, nil
+		}
 	}
-	WriteMetaFile(localIndex, client.BaseDir)
 }
 
-func downloadNewFiles(client RPCClient, localIndex *map[string]*FileMetaData, remoteIndex *map[string]*FileMetaData, blockStoreAddrs []string) error {
+// Downloads run concurrently; the index entries they fill in are created up
+// front, so the workers never touch the localIndex map itself. Deleted
+// directories are removed last, deepest first, once the files in them are
+// gone.
+func downloadNewFiles(client RPCClient, filePool *workerPool, blockPool *workerPool, localIndex *map[string]*FileMetaData, remoteIndex *map[string]*FileMetaData) error {
+	tasks := []func() error{}
+	deletedDirs := []string{}
 	for filename, remoteMetaData := range *remoteIndex {
+		remoteMetaData := remoteMetaData
+		if !isValidFilename(filename) {
+			log.Println("Skipping file with invalid name: ", filename)
+			continue
+		}
+		if localMetaData, ok := (*localIndex)[filename]; ok && isTombstone(remoteMetaData) && isDirectory(localMetaData) {
+			if localMetaData.Version < remoteMetaData.Version {
+				deletedDirs = append(deletedDirs, filename)
+			}
+			continue
+		}
 
 		if localMetaData, ok := (*localIndex)[filename]; ok {
 			// local version is lower
 			if localMetaData.Version < remoteMetaData.Version || (localMetaData.Version == remoteMetaData.Version && !reflect.DeepEqual(localMetaData.BlockHashList, remoteMetaData.BlockHashList)) {
-				if err := downloadFile(client, localMetaData, remoteMetaData, blockStoreAddrs); err != nil {
-					return err
-				}
+				tasks = append(tasks, func() error {
+					return downloadFile(client, blockPool, localMetaData, remoteMetaData)
+				})
 			}
 		} else {
 			// local version not found
 			(*localIndex)[filename] = &FileMetaData{}
 			localMetaData := (*localIndex)[filename]
-			if err := downloadFile(client, localMetaData, remoteMetaData, blockStoreAddrs); err != nil {
-				return err
-			}
+			tasks = append(tasks, func() error {
+				return downloadFile(client, blockPool, localMetaData, remoteMetaData)
+			})
 		}
 	}
+	if err := filePool.run(tasks); err != nil {
+		return err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(deletedDirs)))
+	for _, filename := range deletedDirs {
+		path := filepath.Join(client.BaseDir, filepath.FromSlash(filename))
+		// a directory still holding new local files stays, and is uploaded
+		// again on the next sync
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Println("Could not remove local directory: ", err)
+		}
+		client.writes.record(filename)
+		copyFileMetaData((*localIndex)[filename], (*remoteIndex)[filename])
+	}
 	return nil
 }
 
-func downloadFile(client RPCClient, localMetaData *FileMetaData, remoteMetaData *FileMetaData, blockStoreAddrs []string) error {
-	path := client.BaseDir + "/" + remoteMetaData.Filename
-	file, err := os.Create(path)
+func downloadFile(client RPCClient, blockPool *workerPool, localMetaData *FileMetaData, remoteMetaData *FileMetaData) error {
+	path := filepath.Join(client.BaseDir, filepath.FromSlash(remoteMetaData.Filename))
+
+	//File deleted in server
+	if isTombstone(remoteMetaData) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Println("Could not remove local file: ", err)
+			return err
+		}
+		client.writes.record(remoteMetaData.Filename)
+		copyFileMetaData(localMetaData, remoteMetaData)
+		return nil
+	}
+
+	if isDirectory(remoteMetaData) {
+		if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+			os.Remove(path)
+		}
+		if err := os.MkdirAll(path, 0755); err != nil {
+			log.Println("Could not create local directory: ", err)
+			return err
+		}
+		client.writes.record(remoteMetaData.Filename)
+		copyFileMetaData(localMetaData, remoteMetaData)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Println("Could not create local directory: ", err)
+		return err
+	}
+	if isEmptyFile(remoteMetaData) {
+		if err := ioutil.WriteFile(path, []byte{}, 0644); err != nil {
+			log.Println("Error writing file: ", err)
+			return err
+		}
+		client.writes.record(remoteMetaData.Filename)
+		copyFileMetaData(localMetaData, remoteMetaData)
+		return nil
+	}
+
+	blockReplicas, err := getBlockReplicas(client, remoteMetaData.BlockHashList)
+	if err != nil {
+		return err
+	}
+
+	// the blocks go to a temporary file that replaces the local file once
+	// complete, so a failed download never leaves a truncated file behind
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*"+DOWNLOAD_TEMP_SUFFIX)
 	if err != nil {
 		log.Println("Error creating file: ", err)
+		return err
 	}
-	defer file.Close()
+	defer os.Remove(tmp.Name())
+	if err := writeBlocks(client, blockPool, tmp, remoteMetaData.BlockHashList, blockReplicas); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		log.Println("Error writing file: ", err)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		log.Println("Error writing file: ", err)
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		log.Println("Error writing file: ", err)
+		return err
+	}
+	client.writes.record(remoteMetaData.Filename)
 
-	*localMetaData = *remoteMetaData
+	copyFileMetaData(localMetaData, remoteMetaData)
+	return nil
+}
 
-	//File deleted in server
-	if len(remoteMetaData.BlockHashList) == 1 && remoteMetaData.BlockHashList[0] == "0" {
-		if err := os.Remove(path); err != nil {
-			log.Println("Could not remove local file: ", err)
+// Fetch the blocks of a file DOWNLOAD_BATCH_SIZE at a time and write them to
+// w in order, so that only one batch is held in memory
+func writeBlocks(client RPCClient, blockPool *workerPool, w io.Writer, hashes []string, blockReplicas map[string][]string) error {
+	for start := 0; start < len(hashes); start += DOWNLOAD_BATCH_SIZE {
+		end := start + DOWNLOAD_BATCH_SIZE
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		blocks, err := getBlocksFromReplicas(client, blockPool, hashes[start:end], blockReplicas)
+		if err != nil {
 			return err
 		}
-		return nil
+		for _, hash := range hashes[start:end] {
+			blockData := blocks[hash].BlockData
+			if client.Cipher != nil {
+				if blockData, err = client.Cipher.DecryptBlock(blockData); err != nil {
+					log.Println("Could not decrypt block: ", err)
+					return err
+				}
+			}
+			if _, err := w.Write(blockData); err != nil {
+				log.Println("Error writing file: ", err)
+				return err
+			}
+		}
 	}
+	return nil
+}
 
-	data := ""
-	for _, hash := range remoteMetaData.BlockHashList {
-		c := NewConsistentHashRing(blockStoreAddrs)
-		blockStoreAddr := c.GetResponsibleServer(hash)
+// Fetch blocks with one stream per block server, asking the first replica
+// of each block. Blocks a stream fails to deliver, or delivers corrupted,
+// are fetched one by one from any of their replicas.
+func getBlocksFromReplicas(client RPCClient, blockPool *workerPool, hashes []string, blockReplicas map[string][]string) (map[string]*Block, error) {
+	byServer := map[string][]string{}
+	seen := map[string]bool{}
+	for _, hash := range hashes {
+		if seen[hash] || len(blockReplicas[hash]) == 0 {
+			continue
+		}
+		seen[hash] = true
+		addr := blockReplicas[hash][0]
+		byServer[addr] = append(byServer[addr], hash)
+	}
+
+	blocks := map[string]*Block{}
+	var mtx sync.Mutex
+	tasks := []func() error{}
+	for blockStoreAddr, serverHashes := range byServer {
+		blockStoreAddr, serverHashes := blockStoreAddr, serverHashes
+		tasks = append(tasks, func() error {
+			var received []*Block
+			if err := client.GetBlocks(serverHashes, blockStoreAddr, &received); err != nil {
+				log.Println("Failed to stream blocks from ", blockStoreAddr, ": ", err)
+				return nil
+			}
+			mtx.Lock()
+			defer mtx.Unlock()
+			for i, hash := range serverHashes {
+				block, err := decompressBlock(received[i])
+				if err != nil {
+					log.Println("Failed to decompress block: ", err)
+					continue
+				}
+				if GetBlockHashString(block.BlockData) != hash {
+					log.Println("Block from ", blockStoreAddr, " does not match its hash ", hash)
+					continue
+				}
+				blocks[hash] = block
+			}
+			return nil
+		})
+	}
+	blockPool.run(tasks)
 
+	for _, hash := range hashes {
+		if _, ok := blocks[hash]; ok {
+			continue
+		}
 		var block Block
-		if err := client.GetBlock(hash, blockStoreAddr, &block); err != nil {
+		if err := getBlockFromReplicas(client, hash, blockReplicas[hash], &block); err != nil {
 			log.Println("Failed to get block: ", err)
+			return nil, err
 		}
+		uncompressed, err := decompressBlock(&block)
+		if err != nil {
+			log.Println("Failed to decompress block: ", err)
+			return nil, err
+		}
+		blocks[hash] = uncompressed
+	}
+	return blocks, nil
+}
 
-		data += string(block.BlockData)
+// Fetch a block from the first of its replicas that can serve it intact.
+// A replica holding data that does not match the hash is skipped like one
+// that is unreachable.
+func getBlockFromReplicas(client RPCClient, hash string, replicas []string, block *Block) error {
+	err := fmt.Errorf("no block server holds block %v", hash)
+	for _, blockStoreAddr := range replicas {
+		if err = client.GetBlock(hash, blockStoreAddr, block); err == nil {
+			if err = checkBlockHash(block, hash); err == nil {
+				return nil
+			}
+		}
+		log.Println("Failed to get block from ", blockStoreAddr, ": ", err)
 	}
-	file.WriteString(data)
+	return err
+}
 
+// Check that a block, once decompressed, holds the data hashed to hash
+func checkBlockHash(block *Block, hash string) error {
+	uncompressed, err := decompressBlock(block)
+	if err != nil {
+		return err
+	}
+	if GetBlockHashString(uncompressed.BlockData) != hash {
+		return fmt.Errorf("block does not match its hash %v", hash)
+	}
 	return nil
 }
 
-func uploadNewFiles(client RPCClient, localIndex *map[string]*FileMetaData, remoteIndex *map[string]*FileMetaData, blockStoreAddrs []string) error {
+// Ask the MetaStore where each block lives. Returns the addresses of every
+// block server holding a replica of each hash.
+func getBlockReplicas(client RPCClient, hashes []string) (map[string][]string, error) {
+	blockStoreMap := make(map[string][]string)
+	if err := client.GetBlockStoreMap(hashes, &blockStoreMap); err != nil {
+		log.Println("Failed to get block store map: ", err)
+		return nil, err
+	}
+	blockReplicas := make(map[string][]string)
+	for blockStoreAddr, blockHashes := range blockStoreMap {
+		for _, hash := range blockHashes {
+			blockReplicas[hash] = append(blockReplicas[hash], blockStoreAddr)
+		}
+	}
+	return blockReplicas, nil
+}
+
+func copyFileMetaData(dst *FileMetaData, src *FileMetaData) {
+	dst.Filename = src.Filename
+	dst.Version = src.Version
+	dst.BlockHashList = src.BlockHashList
+}
+
+// Upload every local change the server does not have. A change the server
+// rejects, or a local change to a file the server has another version of,
+// is a conflict: the local edit is kept as a conflict copy, which is
+// uploaded as a new file, and the server's version is downloaded later.
+// Local changes are those that differ from lastSynced, the index of the
+// last sync, however far the server's version is ahead.
+func uploadNewFiles(client RPCClient, filePool *workerPool, blockPool *workerPool, localIndex *map[string]*FileMetaData, remoteIndex *map[string]*FileMetaData, lastSynced map[string]*FileMetaData) error {
 	//Check if server has locas files, upload changes
+	tasks := []func() error{}
+	conflicts := []*FileMetaData{}
+	// entries the server did not take
+	notTaken := []*FileMetaData{}
+	var mtx sync.Mutex
 	for fileName, localMetaData := range *localIndex {
+		localMetaData := localMetaData
+		upload := func() error {
+			err := uploadFile(client, blockPool, localMetaData)
+			if err == errUpdateRejected {
+				mtx.Lock()
+				defer mtx.Unlock()
+				conflicts = append(conflicts, localMetaData)
+				return nil
+			} else if status.Code(err) == codes.PermissionDenied {
+				// a folder shared with us read-only, or no longer shared
+				fmt.Printf("Permission denied: local change to %v not uploaded\n", localMetaData.Filename)
+				mtx.Lock()
+				defer mtx.Unlock()
+				notTaken = append(notTaken, localMetaData)
+				return nil
+			} else if err == errFileChanged {
+				// hashed again and uploaded by the next sync
+				log.Println("File changed while syncing, not uploaded: ", localMetaData.Filename)
+				mtx.Lock()
+				defer mtx.Unlock()
+				notTaken = append(notTaken, localMetaData)
+				return nil
+			}
+			return err
+		}
 		if remoteMetaData, ok := (*remoteIndex)[fileName]; ok {
 			// find a lower version file in remote
 			if remoteMetaData.Version < localMetaData.Version {
-				err := uploadFile(client, localMetaData, blockStoreAddrs)
-				if err != nil {
-					return err
-				}
+				tasks = append(tasks, upload)
+			} else if !reflect.DeepEqual(localMetaData.BlockHashList, remoteMetaData.BlockHashList) &&
+				(remoteMetaData.Version == localMetaData.Version || changedSinceSync(localMetaData, lastSynced)) {
+				// the download would overwrite the local change
+				conflicts = append(conflicts, localMetaData)
 			}
+		} else if isSharedName(fileName) && !sharedParentListed(fileName, *localIndex, *remoteIndex) {
+			// in a folder no longer shared with us; it stays out of the index
+			// so it is never taken for a file the server has
+			log.Println("Not uploading file of a folder no longer shared: ", fileName)
+			delete(*localIndex, fileName)
+			continue
 		} else {
 			// file not found in remote
-			err := uploadFile(client, localMetaData, blockStoreAddrs)
-			if err != nil {
-				return err
+			tasks = append(tasks, upload)
+		}
+	}
+	if err := filePool.run(tasks); err != nil {
+		return err
+	}
+
+	tasks = []func() error{}
+	for _, localMetaData := range conflicts {
+		copyMetaData := saveConflictCopy(client, localMetaData)
+		// make sure the server's version replaces the local one
+		localMetaData.Version = -1
+		if _, ok := (*remoteIndex)[localMetaData.Filename]; !ok {
+			// not listed yet; it comes with the next changes
+			notTaken = append(notTaken, localMetaData)
+		}
+		if copyMetaData == nil {
+			continue
+		}
+		(*localIndex)[copyMetaData.Filename] = copyMetaData
+		tasks = append(tasks, func() error {
+			err := uploadFile(client, blockPool, copyMetaData)
+			if err == errUpdateRejected || err == errFileChanged {
+				log.Println("Conflict copy rejected: ", copyMetaData.Filename)
+				mtx.Lock()
+				defer mtx.Unlock()
+				notTaken = append(notTaken, copyMetaData)
+				return nil
 			}
+			return err
+		})
+	}
+	if err := filePool.run(tasks); err != nil {
+		return err
+	}
+
+	// the index must match the server after the sync, as the next one
+	// only fetches what changed on the server since: entries the server
+	// did not take get its version back, keeping the local file, or are
+	// left out so they count as new local files next time. A deletion the
+	// server did not take, such as that of a read-only shared file or of
+	// the directories leading to a shared folder, is undone by downloading
+	// the server's version, or the next sync would send it again.
+	for _, localMetaData := range notTaken {
+		if remoteMetaData, ok := (*remoteIndex)[localMetaData.Filename]; ok {
+			deleted := isTombstone(localMetaData) && !isTombstone(remoteMetaData)
+			copyFileMetaData(localMetaData, remoteMetaData)
+			if deleted {
+				localMetaData.Version = -1
+			}
+		} else {
+			delete(*localIndex, localMetaData.Filename)
 		}
 	}
 	return nil
 }
 
-func uploadFile(client RPCClient, localMetaData *FileMetaData, blockStoreAddrs []string) error {
-	// todo: upload blocks to their own blockstore
-	path := client.BaseDir + "/" + localMetaData.Filename
+// Whether the local file was changed since the last sync, or is new
+func changedSinceSync(localMetaData *FileMetaData, lastSynced map[string]*FileMetaData) bool {
+	synced, ok := lastSynced[localMetaData.Filename]
+	return !ok || !reflect.DeepEqual(localMetaData.BlockHashList, synced.BlockHashList)
+}
+
+// Remove the files of folders that are no longer shared with us: those
+// below SHARED_DIR the last sync got from the server that it no longer
+// lists. They are dropped from the index, but local changes to them, and
+// directories still holding such changes, are kept on disk. Since their
+// folder is gone from the server they are never uploaded.
+func removeUnshared(client RPCClient, localIndex map[string]*FileMetaData, lastSynced map[string]*FileMetaData, remoteIndex map[string]*FileMetaData) {
+	unshared := []string{}
+	for filename := range lastSynced {
+		if _, listed := remoteIndex[filename]; isSharedName(filename) && !listed {
+			unshared = append(unshared, filename)
+		}
+	}
+
+	// files before the directories holding them
+	sort.Sort(sort.Reverse(sort.StringSlice(unshared)))
+	for _, filename := range unshared {
+		localMetaData, ok := localIndex[filename]
+		delete(localIndex, filename)
+		if !ok || localMetaData.Version != lastSynced[filename].Version || !reflect.DeepEqual(localMetaData.BlockHashList, lastSynced[filename].BlockHashList) {
+			log.Println("Keeping local change to file no longer shared: ", filename)
+			continue
+		}
+		path := filepath.Join(client.BaseDir, filepath.FromSlash(filename))
+		// a directory still holding local files stays
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Println("Could not remove file no longer shared: ", err)
+		}
+		client.writes.record(filename)
+	}
+}
+
+// Whether a local file below SHARED_DIR the server does not list lies in a
+// folder it still lists, possibly below new local directories, so that it
+// may be uploaded
+func sharedParentListed(filename string, localIndex map[string]*FileMetaData, remoteIndex map[string]*FileMetaData) bool {
+	for parent := path.Dir(filename); isSharedName(parent) && parent != SHARED_DIR; parent = path.Dir(parent) {
+		if _, ok := remoteIndex[parent]; ok {
+			return true
+		}
+		if _, ok := localIndex[parent]; !ok {
+			return false
+		}
+	}
+	return false
+}
+
+// Move the local edit of a conflicting file aside to a conflict copy and
+// report it. Returns the index entry of the copy, or nil if there is no
+// local content worth keeping, as for a deletion or a directory.
+func saveConflictCopy(client RPCClient, localMetaData *FileMetaData) *FileMetaData {
+	if isTombstone(localMetaData) || isDirectory(localMetaData) {
+		fmt.Printf("Conflict: %v was changed on the server, local change discarded\n", localMetaData.Filename)
+		return nil
+	}
+	copyName := conflictCopyName(localMetaData.Filename, client.ClientName, time.Now())
+	path := filepath.Join(client.BaseDir, filepath.FromSlash(localMetaData.Filename))
+	if err := os.Rename(path, filepath.Join(client.BaseDir, filepath.FromSlash(copyName))); err != nil {
+		log.Println("Could not save conflict copy: ", err)
+		return nil
+	}
+	client.writes.record(localMetaData.Filename)
+	client.writes.record(copyName)
+	fmt.Printf("Conflict: %v was changed on the server, local version saved as %v\n", localMetaData.Filename, copyName)
+	hashes := localMetaData.BlockHashList
+	if client.Cipher != nil && !client.Cipher.Convergent && !isEmptyFile(localMetaData) {
+		// encrypted blocks depend on the name of their file
+		var err error
+		if hashes, err = hashFile(client, copyName); err != nil {
+			log.Println("Could not hash conflict copy: ", err)
+			return nil
+		}
+	}
+	return &FileMetaData{Filename: copyName, Version: 1, BlockHashList: hashes}
+}
+
+// Name of the conflict copy of filename, e.g.
+// "notes (conflicted copy from laptop 2023-03-01 101500).txt"
+func conflictCopyName(filename string, clientName string, t time.Time) string {
+	dir, base := path.Split(filename)
+	ext := path.Ext(base)
+	if ext == base {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(base, ext)
+	return dir + stem + " (conflicted copy from " + clientName + " " + t.Format("2006-01-02 150405") + ")" + ext
+}
+
+func uploadFile(client RPCClient, blockPool *workerPool, localMetaData *FileMetaData) error {
+	path := filepath.Join(client.BaseDir, filepath.FromSlash(localMetaData.Filename))
 
+	// tombstones, directories and empty files have no blocks to upload
 	var latestVersion int32
-	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) || isDirectory(localMetaData) || isEmptyFile(localMetaData) {
 		err = client.UpdateFile(localMetaData, &latestVersion)
 		if err != nil {
 			log.Println("Could not upload file: ", err)
+			return err
 		}
 		localMetaData.Version = latestVersion
-		return err
+		if latestVersion == -1 {
+			return errUpdateRejected
+		}
+		return nil
 	}
 
 	file, err := os.Open(path)
 	if err != nil {
 		log.Println("Error opening file: ", err)
+		return err
 	}
 	defer file.Close()
 
-	fileStat, _ := os.Stat(path)
-	var numBlocks int = int(math.Ceil(float64(fileStat.Size()) / float64(client.BlockSize)))
-	for i := 0; i < numBlocks; i++ {
-		byteSlice := make([]byte, client.BlockSize)
-		len, err := file.Read(byteSlice)
-		if err != nil && err != io.EOF {
-			log.Println("Error reading bytes from file in basedir: ", err)
+	blockReplicas, err := getBlockReplicas(client, localMetaData.BlockHashList)
+	if err != nil {
+		return err
+	}
+	present := getPresentBlocks(client, blockPool, blockReplicas)
+
+	// blocks are streamed in batches to bound the memory a large file takes.
+	// A file changed since it was hashed is left for the next sync, as its
+	// blocks would not match the hash list.
+	batch := []*Block{}
+	read := 0
+	err = readBlocks(client, localMetaData.Filename, file, func(data []byte) error {
+		if read == len(localMetaData.BlockHashList) || GetBlockHashString(data) != localMetaData.BlockHashList[read] {
+			return errFileChanged
 		}
-		byteSlice = byteSlice[:len]
-		block := Block{BlockData: byteSlice, BlockSize: int32(len)}
-
-		hash := sha256.New()
-		hash.Write(byteSlice)
-		hashBytes := hash.Sum(nil)
-		hashCode := hex.EncodeToString(hashBytes)
-
-		c := NewConsistentHashRing(blockStoreAddrs)
-		blockStoreAddr := c.GetResponsibleServer(hashCode)
-		//blockStoreAddr := getBlockAddr(hashCode, blockStoreAddrs)
-		log.Println("upload blockStoreAddr: ", blockStoreAddr)
-
-		var succ bool
-		if err := client.PutBlock(&block, blockStoreAddr, &succ); err != nil {
-			log.Println("Failed to put block: ", err)
+		read++
+		batch = append(batch, &Block{BlockData: data, BlockSize: int32(len(data))})
+		if len(batch) < UPLOAD_BATCH_SIZE {
+			return nil
+		}
+		err := putBlocksToReplicas(client, blockPool, batch, blockReplicas, present)
+		batch = []*Block{}
+		return err
+	})
+	if err == nil && read < len(localMetaData.BlockHashList) {
+		err = errFileChanged
+	}
+	if err == errFileChanged {
+		return err
+	} else if err != nil {
+		log.Println("Error reading blocks from file in basedir: ", err)
+		return err
+	}
+	if len(batch) > 0 {
+		if err := putBlocksToReplicas(client, blockPool, batch, blockReplicas, present); err != nil {
+			return err
 		}
 	}
 
 	if err := client.UpdateFile(localMetaData, &latestVersion); err != nil {
 		log.Println("Failed to update file: ", err)
-		localMetaData.Version = -1
+		return err
 	}
 	localMetaData.Version = latestVersion
+	if latestVersion == -1 {
+		return errUpdateRejected
+	}
 
 	return nil
 }
 
+// Ask every block server which of the blocks it should hold it already has.
+// Returns, for each server, the set of hashes present there. A server that
+// cannot answer is assumed to have none of them.
+func getPresentBlocks(client RPCClient, blockPool *workerPool, blockReplicas map[string][]string) map[string]map[string]bool {
+	byServer := map[string][]string{}
+	for hash, replicas := range blockReplicas {
+		for _, blockStoreAddr := range replicas {
+			byServer[blockStoreAddr] = append(byServer[blockStoreAddr], hash)
+		}
+	}
+
+	present := map[string]map[string]bool{}
+	var mtx sync.Mutex
+	tasks := []func() error{}
+	for blockStoreAddr, hashes := range byServer {
+		blockStoreAddr, hashes := blockStoreAddr, hashes
+		tasks = append(tasks, func() error {
+			var found []string
+			if err := client.HasBlocks(hashes, blockStoreAddr, &found); err != nil {
+				log.Println("Failed to check blocks on ", blockStoreAddr, ": ", err)
+				return nil
+			}
+			mtx.Lock()
+			defer mtx.Unlock()
+			present[blockStoreAddr] = map[string]bool{}
+			for _, hash := range found {
+				present[blockStoreAddr][hash] = true
+			}
+			return nil
+		})
+	}
+	blockPool.run(tasks)
+	return present
+}
+
+// Store blocks on all of their replicas, with one stream per block server,
+// skipping the servers present says already have a block. Blocks a stream
+// failed to store are put one by one with PutBlock, which is retried. Blocks
+// are sent compressed with client.Compression. The upload succeeds once a
+// majority of the replicas of every block have stored it.
+func putBlocksToReplicas(client RPCClient, blockPool *workerPool, blocks []*Block, blockReplicas map[string][]string, present map[string]map[string]bool) error {
+	byServer := map[string][]*Block{}
+	hashes := []string{}
+	hashOf := map[*Block]string{}
+	stored := map[string]int{}
+	for _, block := range blocks {
+		hash := GetBlockHashString(block.BlockData)
+		if _, ok := stored[hash]; ok {
+			continue
+		}
+		stored[hash] = 0
+		hashes = append(hashes, hash)
+		block, err := compressBlock(block, client.Compression)
+		if err != nil {
+			log.Println("Failed to compress block: ", err)
+			return err
+		}
+		hashOf[block] = hash
+		for _, blockStoreAddr := range blockReplicas[hash] {
+			if present[blockStoreAddr][hash] {
+				stored[hash]++
+				continue
+			}
+			byServer[blockStoreAddr] = append(byServer[blockStoreAddr], block)
+		}
+	}
+
+	var mtx sync.Mutex
+	tasks := []func() error{}
+	for blockStoreAddr, serverBlocks := range byServer {
+		blockStoreAddr, serverBlocks := blockStoreAddr, serverBlocks
+		tasks = append(tasks, func() error {
+			log.Printf("uploading %v blocks to %v\n", len(serverBlocks), blockStoreAddr)
+			var succ bool
+			err := client.PutBlocks(serverBlocks, blockStoreAddr, &succ)
+			if err == nil && succ {
+				mtx.Lock()
+				defer mtx.Unlock()
+				for _, block := range serverBlocks {
+					stored[hashOf[block]]++
+				}
+				return nil
+			}
+			log.Println("Failed to stream blocks to ", blockStoreAddr, ", putting them one by one: ", err)
+			// the stream acknowledges all of its blocks or none, so they are
+			// all sent again, each retried on its own
+			for _, block := range serverBlocks {
+				if err := client.PutBlock(block, blockStoreAddr, &succ); err != nil || !succ {
+					log.Println("Failed to put block: ", err)
+					// the server is likely down; the others may make the quorum
+					return nil
+				}
+				mtx.Lock()
+				stored[hashOf[block]]++
+				mtx.Unlock()
+			}
+			return nil
+		})
+	}
+	blockPool.run(tasks)
+	for _, hash := range hashes {
+		replicas := len(blockReplicas[hash])
+		if stored[hash] <= replicas/2 {
+			return fmt.Errorf("block %v stored on %v of %v replicas, no write quorum", hash, stored[hash], replicas)
+		}
+	}
+	return nil
+}
+
+// Tombstone every indexed file or directory no longer on disk. Deleting a
+// directory deletes everything below it, so a whole subtree is tombstoned.
 func checkDeletedFiles(localIndex *map[string]*FileMetaData, hashMap map[string][]string) error {
 	// deleted files
 	for file, metaData := range *localIndex {
 		if _, ok := hashMap[file]; !ok {
-			if len(metaData.BlockHashList) != 1 || metaData.BlockHashList[0] != "0" {
+			if !isTombstone(metaData) {
 				metaData.Version++
 				metaData.BlockHashList = []string{"0"}
 			}
@@ -212,41 +812,149 @@ func checkDeletedFiles(localIndex *map[string]*FileMetaData, hashMap map[string]
 	return nil
 }
 
-func syncLocalIndex(client RPCClient, localIndex *map[string]*FileMetaData, files []fs.FileInfo) (hashMap map[string][]string, err error) {
+// Hash every file under the base directory, concurrently, and update the
+// local index with the files and directories that changed. The index itself
+// is only updated once all files are hashed. If changed is set, indexed
+// files it does not cover keep their hashes.
+func syncLocalIndex(client RPCClient, filePool *workerPool, localIndex *map[string]*FileMetaData, files map[string]fs.FileInfo, changed map[string]bool) (hashMap map[string][]string, err error) {
 	hashMap = make(map[string][]string)
-	for _, file := range files {
-		if file.Name() == DEFAULT_META_FILENAME {
+	var mtx sync.Mutex
+	tasks := []func() error{}
+	for filename, file := range files {
+		filename := filename
+		if file.IsDir() {
+			hashMap[filename] = []string{DIRECTORY_HASHVALUE}
 			continue
 		}
-		var numBlocks int = int(math.Ceil(float64(file.Size()) / float64(client.BlockSize)))
-		fileToRead, err := os.Open(client.BaseDir + "/" + file.Name())
-		if err != nil {
-			log.Println(err)
-			return nil, err
+		if file.Size() == 0 {
+			hashMap[filename] = []string{EMPTYFILE_HASHVALUE}
+			continue
 		}
-
-		for i := 0; i < numBlocks; i++ {
-			byteSlice := make([]byte, client.BlockSize)
-			n, err := fileToRead.Read(byteSlice)
+		if indexed, ok := (*localIndex)[filename]; ok && changed != nil && !coversPath(changed, filename) &&
+			!isTombstone(indexed) && !isDirectory(indexed) && !isEmptyFile(indexed) {
+			hashMap[filename] = indexed.BlockHashList
+			continue
+		}
+		tasks = append(tasks, func() error {
+			hashes, err := hashFile(client, filename)
 			if err != nil {
 				log.Println(err)
-				return nil, err
+				return err
 			}
-			byteSlice = byteSlice[:n]
-			hash := GetBlockHashString(byteSlice)
-			hashMap[file.Name()] = append(hashMap[file.Name()], hash)
-		}
+			mtx.Lock()
+			defer mtx.Unlock()
+			hashMap[filename] = hashes
+			return nil
+		})
+	}
+	if err := filePool.run(tasks); err != nil {
+		return nil, err
+	}
 
-		if val, ok := (*localIndex)[file.Name()]; ok {
-			if !reflect.DeepEqual(hashMap[file.Name()], val.BlockHashList) {
-				(*localIndex)[file.Name()].BlockHashList = hashMap[file.Name()]
-				(*localIndex)[file.Name()].Version += 1
+	for filename, hashes := range hashMap {
+		if val, ok := (*localIndex)[filename]; ok {
+			if !reflect.DeepEqual(hashes, val.BlockHashList) {
+				(*localIndex)[filename].BlockHashList = hashes
+				(*localIndex)[filename].Version += 1
 			}
 		} else {
-			newMetaFile := FileMetaData{Filename: file.Name(), Version: 1, BlockHashList: hashMap[file.Name()]}
-			(*localIndex)[file.Name()] = &newMetaFile
+			newMetaFile := FileMetaData{Filename: filename, Version: 1, BlockHashList: hashes}
+			(*localIndex)[filename] = &newMetaFile
 		}
 	}
 
 	return hashMap, nil
 }
+
+// Whether filename, or one of the directories above it, is in paths
+func coversPath(paths map[string]bool, filename string) bool {
+	for ; filename != "." && filename != "/"; filename = path.Dir(filename) {
+		if paths[filename] {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash list of a file in the base directory, split by the client's chunker
+func hashFile(client RPCClient, filename string) ([]string, error) {
+	fileToRead, err := os.Open(filepath.Join(client.BaseDir, filepath.FromSlash(filename)))
+	if err != nil {
+		return nil, err
+	}
+	defer fileToRead.Close()
+
+	hashes := []string{}
+	err = readBlocks(client, filename, fileToRead, func(data []byte) error {
+		hashes = append(hashes, GetBlockHashString(data))
+		return nil
+	})
+	return hashes, err
+}
+
+// Split the content of filename into blocks with the client's chunker, and
+// encrypt them if the client encrypts. Blocks of a shared folder are
+// encrypted under the owner's name for the file, so that both encrypt them
+// the same way.
+func readBlocks(client RPCClient, filename string, r io.Reader, emit func(data []byte) error) error {
+	if _, path, ok := splitSharedName(filename); ok {
+		filename = path
+	}
+	return client.Chunker.Chunks(r, func(data []byte) error {
+		if client.Cipher != nil {
+			data = client.Cipher.EncryptBlock(filename, data)
+		}
+		return emit(data)
+	})
+}
+
+// List every file and directory below baseDir, keyed by its path relative
+// to baseDir with "/" as separator. The index file, downloads in progress
+// and anything that is neither a regular file nor a directory are left out.
+func walkBaseDir(baseDir string) (map[string]fs.FileInfo, error) {
+	files := map[string]fs.FileInfo{}
+	err := filepath.Walk(baseDir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || rel == DEFAULT_META_FILENAME || strings.HasSuffix(rel, DOWNLOAD_TEMP_SUFFIX) {
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			log.Println("Skipping special file: ", path)
+			return nil
+		}
+		files[filepath.ToSlash(rel)] = info
+		return nil
+	})
+	return files, err
+}
+
+// Reject names from the server that would escape the base directory
+func isValidFilename(filename string) bool {
+	if filename == "" || path.IsAbs(filename) || strings.Contains(filename, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(filename, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func isTombstone(fileMetaData *FileMetaData) bool {
+	return len(fileMetaData.BlockHashList) == 1 && fileMetaData.BlockHashList[0] == TOMBSTONE_HASHVALUE
+}
+
+func isDirectory(fileMetaData *FileMetaData) bool {
+	return len(fileMetaData.BlockHashList) == 1 && fileMetaData.BlockHashList[0] == DIRECTORY_HASHVALUE
+}
+
+func isEmptyFile(fileMetaData *FileMetaData) bool {
+	return len(fileMetaData.BlockHashList) == 1 && fileMetaData.BlockHashList[0] == EMPTYFILE_HASHVALUE
+}
@@ -0,0 +1,521 @@
+package surfstore
+
+import (
+	context "context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	sync "sync"
+	"sync/atomic"
+	"testing"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// Serve a MetaStore and blockStore on one local port, and return its
+// address
+func startTestServer(t *testing.T, blockStore BlockStoreServer) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	RegisterMetaStoreServer(server, NewMetaStore([]string{l.Addr().String()}))
+	RegisterBlockStoreServer(server, blockStore)
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+	return l.Addr().String()
+}
+
+// Serve a MetaStore that replicates every block on all of blockStores, each
+// served on a local port of its own, and return the MetaStore's address
+func startReplicatedTestServer(t *testing.T, blockStores ...BlockStoreServer) string {
+	serve := func(register func(server *grpc.Server)) string {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := grpc.NewServer()
+		register(server)
+		go server.Serve(l)
+		t.Cleanup(server.Stop)
+		return l.Addr().String()
+	}
+	addrs := []string{}
+	for _, blockStore := range blockStores {
+		blockStore := blockStore
+		addrs = append(addrs, serve(func(server *grpc.Server) { RegisterBlockStoreServer(server, blockStore) }))
+	}
+	metaStore := NewMetaStore(addrs)
+	metaStore.ReplicationFactor = len(addrs)
+	return serve(func(server *grpc.Server) { RegisterMetaStoreServer(server, metaStore) })
+}
+
+func newTestClient(t *testing.T, addr string, name string) RPCClient {
+	client := NewSurfstoreRPCClient(addr, t.TempDir(), 1024)
+	client.ClientName = name
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func writeTestFile(t *testing.T, client RPCClient, filename string, data string) {
+	if err := ioutil.WriteFile(filepath.Join(client.BaseDir, filename), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func syncTestClient(t *testing.T, client RPCClient) {
+	if _, _, err := clientSync(client, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncKeepsLocalEditBehindServer(t *testing.T) {
+	addr := startTestServer(t, NewBlockStore())
+	a := newTestClient(t, addr, "a")
+	b := newTestClient(t, addr, "b")
+
+	writeTestFile(t, a, "notes.txt", "first")
+	syncTestClient(t, a)
+	syncTestClient(t, b)
+
+	// the server goes two versions ahead of a, which edits its copy meanwhile
+	for _, data := range []string{"second", "third"} {
+		writeTestFile(t, b, "notes.txt", data)
+		syncTestClient(t, b)
+	}
+	writeTestFile(t, a, "notes.txt", "local edit")
+	syncTestClient(t, a)
+
+	data, err := ioutil.ReadFile(filepath.Join(a.BaseDir, "notes.txt"))
+	if err != nil || string(data) != "third" {
+		t.Errorf("notes.txt holds %q, %v; expected the server's version", data, err)
+	}
+	entries, err := os.ReadDir(a.BaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copies := 0
+	for _, entry := range entries {
+		if !strings.Contains(entry.Name(), "conflicted copy from a") {
+			continue
+		}
+		copies++
+		data, err := ioutil.ReadFile(filepath.Join(a.BaseDir, entry.Name()))
+		if err != nil || string(data) != "local edit" {
+			t.Errorf("conflict copy holds %q, %v", data, err)
+		}
+	}
+	if copies != 1 {
+		t.Errorf("%v conflict copies, expected 1", copies)
+	}
+
+	// the copy reaches the other client
+	syncTestClient(t, b)
+	if index, err := LoadMetaFromMetaFile(b.BaseDir); err != nil || len(index) != 2 {
+		t.Errorf("other client has %v files, %v; expected the file and its copy", len(index), err)
+	}
+}
+
+func TestSyncCleanFileBehindServer(t *testing.T) {
+	addr := startTestServer(t, NewBlockStore())
+	a := newTestClient(t, addr, "a")
+	b := newTestClient(t, addr, "b")
+
+	writeTestFile(t, a, "notes.txt", "first")
+	syncTestClient(t, a)
+	syncTestClient(t, b)
+	for _, data := range []string{"second", "third"} {
+		writeTestFile(t, b, "notes.txt", data)
+		syncTestClient(t, b)
+	}
+	syncTestClient(t, a)
+
+	entries, err := os.ReadDir(a.BaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), "conflicted copy") {
+			t.Errorf("unexpected conflict copy %v", entry.Name())
+		}
+	}
+	data, err := ioutil.ReadFile(filepath.Join(a.BaseDir, "notes.txt"))
+	if err != nil || string(data) != "third" {
+		t.Errorf("notes.txt holds %q, %v; expected the server's version", data, err)
+	}
+}
+
+// A block server whose PutBlocks streams always fail
+type failingStreamBlockStore struct {
+	*BlockStore
+}
+
+func (bs failingStreamBlockStore) PutBlocks(stream BlockStore_PutBlocksServer) error {
+	return status.Error(codes.Unavailable, "stream failed")
+}
+
+func TestUploadFallsBackToPutBlock(t *testing.T) {
+	blockStore := NewBlockStore()
+	addr := startTestServer(t, failingStreamBlockStore{blockStore})
+	client := newTestClient(t, addr, "a")
+	writeTestFile(t, client, "data", strings.Repeat("x", 3000))
+	syncTestClient(t, client)
+
+	index, err := LoadMetaFromMetaFile(client.BaseDir)
+	if err != nil || index["data"] == nil || index["data"].Version != 1 {
+		t.Fatalf("file not uploaded: %v, %v", index, err)
+	}
+	for _, hash := range index["data"].BlockHashList {
+		if ok, err := blockStore.Backend.Has(hash); !ok || err != nil {
+			t.Errorf("block %v not stored: %v", hash, err)
+		}
+	}
+}
+
+// A block server that is down for writes
+type unwritableBlockStore struct {
+	*BlockStore
+}
+
+func (bs unwritableBlockStore) PutBlock(ctx context.Context, block *Block) (*Success, error) {
+	return nil, status.Error(codes.Unavailable, "server down")
+}
+
+func (bs unwritableBlockStore) PutBlocks(stream BlockStore_PutBlocksServer) error {
+	return status.Error(codes.Unavailable, "server down")
+}
+
+func TestUploadWriteQuorum(t *testing.T) {
+	tests := []struct {
+		name     string
+		down     int
+		uploaded bool
+	}{
+		{"all replicas", 0, true},
+		{"majority", 1, true},
+		{"minority", 2, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blockStores := []*BlockStore{NewBlockStore(), NewBlockStore(), NewBlockStore()}
+			servers := []BlockStoreServer{}
+			for i, blockStore := range blockStores {
+				if i < test.down {
+					servers = append(servers, unwritableBlockStore{blockStore})
+				} else {
+					servers = append(servers, blockStore)
+				}
+			}
+			client := newTestClient(t, startReplicatedTestServer(t, servers...), "a")
+			client.Retry = nil
+			writeTestFile(t, client, "data", strings.Repeat("x", 1000)+strings.Repeat("y", 1000))
+
+			_, _, err := clientSync(client, nil)
+			if uploaded := err == nil; uploaded != test.uploaded {
+				t.Fatalf("sync: %v, expected upload %v", err, test.uploaded)
+			}
+			var remoteIndex map[string]*FileMetaData
+			if err := client.GetFileInfoMap(&remoteIndex); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := remoteIndex["data"]; ok != test.uploaded {
+				t.Errorf("file on the MetaStore: %v, expected %v", ok, test.uploaded)
+			}
+			if !test.uploaded {
+				return
+			}
+			// every block is on each replica that was up
+			for _, hash := range remoteIndex["data"].BlockHashList {
+				for i, blockStore := range blockStores {
+					if has, err := blockStore.Backend.Has(hash); err != nil || has != (i >= test.down) {
+						t.Errorf("block %v on server %v: %v, %v", hash, i, has, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// A block server returning every block with its data changed
+type corruptingBlockStore struct {
+	*BlockStore
+}
+
+func (bs corruptingBlockStore) GetBlock(ctx context.Context, blockHash *BlockHash) (*Block, error) {
+	block, err := bs.BlockStore.GetBlock(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	data := append([]byte{}, block.BlockData...)
+	data[0]++
+	return &Block{BlockData: data, BlockSize: block.BlockSize, Compression: block.Compression}, nil
+}
+
+func (bs corruptingBlockStore) GetBlocks(blockHashes *BlockHashes, stream BlockStore_GetBlocksServer) error {
+	for _, hash := range blockHashes.Hashes {
+		block, err := bs.GetBlock(stream.Context(), &BlockHash{Hash: hash})
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDownloadSkipsCorruptedReplicas(t *testing.T) {
+	tests := []struct {
+		name       string
+		corrupted  int
+		downloaded bool
+	}{
+		{"one replica", 1, true},
+		{"every replica", 2, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			servers := []BlockStoreServer{}
+			for i := 0; i < 2; i++ {
+				if i < test.corrupted {
+					servers = append(servers, corruptingBlockStore{NewBlockStore()})
+				} else {
+					servers = append(servers, NewBlockStore())
+				}
+			}
+			addr := startReplicatedTestServer(t, servers...)
+			a := newTestClient(t, addr, "a")
+			b := newTestClient(t, addr, "b")
+			data := strings.Repeat("x", 1000) + strings.Repeat("y", 1000)
+			writeTestFile(t, a, "data", data)
+			syncTestClient(t, a)
+
+			_, _, err := clientSync(b, nil)
+			if downloaded := err == nil; downloaded != test.downloaded {
+				t.Fatalf("sync: %v, expected download %v", err, test.downloaded)
+			}
+			read, err := ioutil.ReadFile(filepath.Join(b.BaseDir, "data"))
+			if test.downloaded && (err != nil || string(read) != data) {
+				t.Errorf("data not downloaded intact: %v bytes, %v", len(read), err)
+			} else if !test.downloaded && !os.IsNotExist(err) {
+				t.Errorf("data written from corrupted blocks: %v bytes, %v", len(read), err)
+			}
+		})
+	}
+}
+
+// A MetaStore that runs changed when asked where the block hashed from the
+// content before is stored
+type changingMetaStore struct {
+	*MetaStore
+	before  string
+	changed func()
+}
+
+func (m changingMetaStore) GetBlockStoreMap(ctx context.Context, blockHashesIn *BlockHashes) (*BlockStoreMap, error) {
+	for _, hash := range blockHashesIn.Hashes {
+		if hash == GetBlockHashString([]byte(m.before)) {
+			m.changed()
+		}
+	}
+	return m.MetaStore.GetBlockStoreMap(ctx, blockHashesIn)
+}
+
+func TestSyncSkipsFileChangedAfterHashing(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	metaStore := changingMetaStore{MetaStore: NewMetaStore([]string{addr}), before: "before"}
+	server := grpc.NewServer()
+	RegisterMetaStoreServer(server, &metaStore)
+	RegisterBlockStoreServer(server, NewBlockStore())
+	go server.Serve(l)
+	defer server.Stop()
+
+	client := newTestClient(t, addr, "a")
+	var once sync.Once
+	metaStore.changed = func() {
+		once.Do(func() { writeTestFile(t, client, "changing.txt", "after") })
+	}
+	writeTestFile(t, client, "changing.txt", "before")
+	writeTestFile(t, client, "stable.txt", "stable")
+
+	// the file changes between its hashing and its upload
+	syncTestClient(t, client)
+	index, err := LoadMetaFromMetaFile(client.BaseDir)
+	if err != nil || index["stable.txt"] == nil || index["stable.txt"].Version != 1 {
+		t.Fatalf("other file not uploaded: %v, %v", index, err)
+	}
+	if index["changing.txt"] != nil {
+		t.Errorf("changed file indexed as %v", index["changing.txt"])
+	}
+
+	syncTestClient(t, client)
+	other := newTestClient(t, addr, "b")
+	syncTestClient(t, other)
+	data, err := ioutil.ReadFile(filepath.Join(other.BaseDir, "changing.txt"))
+	if err != nil || string(data) != "after" {
+		t.Errorf("changing.txt synced as %q, %v; expected its content after the change", data, err)
+	}
+}
+
+func TestDownloadReplacesFileOnceComplete(t *testing.T) {
+	blockStore := NewBlockStore()
+	addr := startTestServer(t, blockStore)
+	a := newTestClient(t, addr, "a")
+	b := newTestClient(t, addr, "b")
+
+	// more blocks than one download batch
+	var data strings.Builder
+	for i := 0; data.Len() < 1024*(DOWNLOAD_BATCH_SIZE+10); i++ {
+		fmt.Fprintln(&data, "line", i)
+	}
+	writeTestFile(t, a, "data", data.String())
+	syncTestClient(t, a)
+	syncTestClient(t, b)
+	read, err := ioutil.ReadFile(filepath.Join(b.BaseDir, "data"))
+	if err != nil || string(read) != data.String() {
+		t.Fatalf("data not downloaded intact: %v of %v bytes, %v", len(read), data.Len(), err)
+	}
+
+	// a download that fails leaves the file as it was
+	writeTestFile(t, a, "data", data.String()+"more")
+	syncTestClient(t, a)
+	index, err := LoadMetaFromMetaFile(a.BaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := index["data"].BlockHashList
+	if err := blockStore.Backend.Delete(hashes[len(hashes)-1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := clientSync(b, nil); err == nil {
+		t.Fatal("synced a file with a missing block")
+	}
+	read, err = ioutil.ReadFile(filepath.Join(b.BaseDir, "data"))
+	if err != nil || string(read) != data.String() {
+		t.Errorf("data changed by a failed download: %v of %v bytes, %v", len(read), data.Len(), err)
+	}
+	entries, err := os.ReadDir(b.BaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), DOWNLOAD_TEMP_SUFFIX) {
+			t.Errorf("download left %v behind", entry.Name())
+		}
+	}
+}
+
+// A backend counting the blocks put into it
+type countingBackend struct {
+	BlockBackend
+	puts int64
+}
+
+func (b *countingBackend) Put(hash string, block *Block) error {
+	atomic.AddInt64(&b.puts, 1)
+	return b.BlockBackend.Put(hash, block)
+}
+
+func TestUploadSkipsPresentBlocks(t *testing.T) {
+	blockStore := NewBlockStore()
+	backend := &countingBackend{BlockBackend: blockStore.Backend}
+	blockStore.Backend = backend
+	addr := startTestServer(t, blockStore)
+	a := newTestClient(t, addr, "a")
+	b := newTestClient(t, addr, "b")
+	blocks := func(fills ...string) string {
+		data := ""
+		for _, fill := range fills {
+			data += strings.Repeat(fill, 1024)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name   string
+		client RPCClient
+		file   string
+		data   string
+		puts   int64
+	}{
+		{"new file", a, "data", blocks("a", "b", "c", "d"), 4},
+		{"one block changed", a, "data", blocks("a", "b", "e", "d"), 1},
+		{"same blocks in another file", b, "copy", blocks("d", "a", "e"), 0},
+	}
+	for _, test := range tests {
+		atomic.StoreInt64(&backend.puts, 0)
+		writeTestFile(t, test.client, test.file, test.data)
+		ClientSync(test.client)
+		if puts := atomic.LoadInt64(&backend.puts); puts != test.puts {
+			t.Errorf("%v: %v blocks put, expected %v", test.name, puts, test.puts)
+		}
+	}
+}
+
+func TestSyncDirectories(t *testing.T) {
+	addr := startTestServer(t, NewBlockStore())
+	a := newTestClient(t, addr, "a")
+	b := newTestClient(t, addr, "b")
+	exists := func(client RPCClient, filename string) bool {
+		_, err := os.Stat(filepath.Join(client.BaseDir, filepath.FromSlash(filename)))
+		return err == nil
+	}
+
+	for _, dir := range []string{"dir/sub", "dir/empty", "other"} {
+		if err := os.MkdirAll(filepath.Join(a.BaseDir, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, a, "dir/sub/file.txt", "nested")
+	writeTestFile(t, a, "other/file.txt", "other")
+	syncTestClient(t, a)
+	syncTestClient(t, b)
+	data, err := ioutil.ReadFile(filepath.Join(b.BaseDir, "dir", "sub", "file.txt"))
+	if err != nil || string(data) != "nested" {
+		t.Fatalf("nested file synced as %q, %v", data, err)
+	}
+	if !exists(b, "dir/empty") {
+		t.Fatal("empty directory not synced")
+	}
+
+	// deleting a directory deletes everything below it
+	if err := os.RemoveAll(filepath.Join(a.BaseDir, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	syncTestClient(t, a)
+	index, err := LoadMetaFromMetaFile(a.BaseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{"dir", "dir/sub", "dir/sub/file.txt", "dir/empty"} {
+		if index[filename] == nil || !isTombstone(index[filename]) || index[filename].Version != 2 {
+			t.Errorf("%v indexed as %v, expected a tombstone", filename, index[filename])
+		}
+	}
+	// b gets a new file into the directory before learning of the deletion
+	writeTestFile(t, b, "dir/new.txt", "new")
+	syncTestClient(t, b)
+	for filename, expected := range map[string]bool{"dir/sub": false, "dir/empty": false, "dir/new.txt": true, "other/file.txt": true} {
+		if exists(b, filename) != expected {
+			t.Errorf("%v exists: %v, expected %v", filename, !expected, expected)
+		}
+	}
+
+	// the directory kept for the new file comes back on the server
+	syncTestClient(t, b)
+	syncTestClient(t, a)
+	data, err = ioutil.ReadFile(filepath.Join(a.BaseDir, "dir", "new.txt"))
+	if err != nil || string(data) != "new" {
+		t.Errorf("new file synced as %q, %v", data, err)
+	}
+}
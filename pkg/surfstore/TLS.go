@@ -0,0 +1,91 @@
+package surfstore
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSConfig holds the certificate paths of a server or client. A server
+// serves TLS with CertFile and KeyFile and, with MutualTLS, only accepts
+// clients presenting a certificate signed by CAFile. A client verifies
+// servers against CAFile (the system roots if empty) and presents CertFile
+// and KeyFile, if set, as its own certificate. Servers present their own
+// certificate when calling other servers, so with MutualTLS it has to be
+// valid for client authentication too.
+type TLSConfig struct {
+	CertFile  string
+	KeyFile   string
+	CAFile    string
+	MutualTLS bool
+}
+
+func (c *TLSConfig) loadCertificates() ([]tls.Certificate, error) {
+	if c.CertFile == "" && c.KeyFile == "" {
+		return nil, nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, errors.New("a certificate needs both a cert and a key file")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return []tls.Certificate{cert}, nil
+}
+
+func (c *TLSConfig) loadCA() (*x509.CertPool, error) {
+	if c.CAFile == "" {
+		return nil, nil
+	}
+	pem, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %v", c.CAFile)
+	}
+	return pool, nil
+}
+
+// Option making a gRPC server serve TLS
+func (c *TLSConfig) ServerOption() (grpc.ServerOption, error) {
+	certs, err := c.loadCertificates()
+	if err != nil {
+		return nil, err
+	}
+	if certs == nil {
+		return nil, errors.New("serving TLS needs a cert and a key file")
+	}
+	config := &tls.Config{Certificates: certs, MinVersion: tls.VersionTLS12}
+	if c.MutualTLS {
+		if c.CAFile == "" {
+			return nil, errors.New("mutual TLS needs a CA file to verify clients")
+		}
+		if config.ClientCAs, err = c.loadCA(); err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return grpc.Creds(credentials.NewTLS(config)), nil
+}
+
+// Option making gRPC connections use TLS
+func (c *TLSConfig) DialOption() (grpc.DialOption, error) {
+	certs, err := c.loadCertificates()
+	if err != nil {
+		return nil, err
+	}
+	roots, err := c.loadCA()
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: certs, RootCAs: roots, MinVersion: tls.VersionTLS12}
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+}
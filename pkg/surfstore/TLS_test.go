@@ -0,0 +1,166 @@
+package surfstore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	grpc "google.golang.org/grpc"
+)
+
+// A certificate authority issuing test certificates
+type testCA struct {
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	ca := &testCA{dir: t.TempDir()}
+	ca.cert, ca.key, ca.file = ca.issue(t, name, &x509.Certificate{IsCA: true, KeyUsage: x509.KeyUsageCertSign, BasicConstraintsValid: true})
+	return ca
+}
+
+// Write a certificate for name, valid for 127.0.0.1, and its key, and
+// return their files
+func (ca *testCA) issueFiles(t *testing.T, name string) (string, string) {
+	_, key, certFile := ca.issue(t, name, &x509.Certificate{
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	})
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(ca.dir, name+".key")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// Write the key of a fresh certificate, which matches no other one
+func (ca *testCA) issueKeyFile(t *testing.T) string {
+	_, keyFile := ca.issueFiles(t, "other")
+	return keyFile
+}
+
+// Sign template for name, self-signed if ca has no certificate yet
+func (ca *testCA) issue(t *testing.T, name string, template *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.Subject = pkix.Name{CommonName: name}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	parent, signer := template, key
+	if ca.cert != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(ca.dir, name+".pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, file
+}
+
+func TestTLSConfigLoading(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	certFile, keyFile := ca.issueFiles(t, "server")
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		config   TLSConfig
+		serverOK bool
+		dialOK   bool
+	}{
+		{"TLS", TLSConfig{CertFile: certFile, KeyFile: keyFile}, true, true},
+		{"mutual TLS", TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: ca.file, MutualTLS: true}, true, true},
+		{"client without certificate", TLSConfig{CAFile: ca.file}, false, true},
+		{"cert without key", TLSConfig{CertFile: certFile}, false, false},
+		{"key without cert", TLSConfig{KeyFile: keyFile}, false, false},
+		{"key of another certificate", TLSConfig{CertFile: certFile, KeyFile: ca.issueKeyFile(t)}, false, false},
+		{"mutual TLS without CA", TLSConfig{CertFile: certFile, KeyFile: keyFile, MutualTLS: true}, false, true},
+		{"CA without certificates", TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: notPEM, MutualTLS: true}, false, false},
+		{"missing CA", TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.config.ServerOption(); (err == nil) != test.serverOK {
+				t.Errorf("server option: %v, expected success %v", err, test.serverOK)
+			}
+			if _, err := test.config.DialOption(); (err == nil) != test.dialOK {
+				t.Errorf("dial option: %v, expected success %v", err, test.dialOK)
+			}
+		})
+	}
+}
+
+func TestMutualTLSRejectsUnauthenticatedPeers(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	certFile, keyFile := ca.issueFiles(t, "server")
+	serverOption, err := (&TLSConfig{CertFile: certFile, KeyFile: keyFile, CAFile: ca.file, MutualTLS: true}).ServerOption()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(serverOption)
+	RegisterBlockStoreServer(server, NewBlockStore())
+	go server.Serve(l)
+	defer server.Stop()
+
+	clientCert, clientKey := ca.issueFiles(t, "client")
+	other := newTestCA(t, "other ca")
+	otherCert, otherKey := other.issueFiles(t, "client")
+	tests := []struct {
+		name   string
+		config *TLSConfig
+		ok     bool
+	}{
+		{"client certificate", &TLSConfig{CertFile: clientCert, KeyFile: clientKey, CAFile: ca.file}, true},
+		{"no client certificate", &TLSConfig{CAFile: ca.file}, false},
+		{"certificate of another CA", &TLSConfig{CertFile: otherCert, KeyFile: otherKey, CAFile: ca.file}, false},
+		{"plaintext", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := RPCClient{}
+			if test.config != nil {
+				if client.DialOption, err = test.config.DialOption(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var hashes []string
+			if err := client.HasBlocks([]string{"h"}, l.Addr().String(), &hashes); (err == nil) != test.ok {
+				t.Errorf("call: %v, expected success %v", err, test.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package surfstore
+
+import (
+	sync "sync"
+)
+
+// Number of files and block transfers the client works on at once, unless
+// set with -j
+const DEFAULT_CONCURRENCY int = 4
+
+// A workerPool runs tasks with at most size of them at a time. Tasks run by
+// a pool must not wait on tasks of the same pool, or they could deadlock;
+// the client uses one pool for files and another for block transfers.
+type workerPool struct {
+	sem chan struct{}
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+	return &workerPool{sem: make(chan struct{}, size)}
+}
+
+// Run every task, wait for all of them to finish and return the first error
+func (p *workerPool) run(tasks []func() error) error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, task := range tasks {
+		wg.Add(1)
+		p.sem <- struct{}{}
+		go func(task func() error) {
+			defer wg.Done()
+			defer func() { <-p.sem }()
+			if err := task(); err != nil {
+				once.Do(func() { firstErr = err })
+			}
+		}(task)
+	}
+	wg.Wait()
+	return firstErr
+}